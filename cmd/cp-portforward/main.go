@@ -0,0 +1,215 @@
+// cp-portforward 는 로컬 포트로 들어온 TCP 연결을 cp-remote-api 의 /ws/portforward 로 중계한다.
+//
+//	cp-portforward -server https://remote-api.example.com -token $TOKEN \
+//	  -cluster c1 -namespace ns1 -pod app-0 -port 5005 -local 15005
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+)
+
+// 서버가 텍스트 프레임으로 보내는 제어 메시지 (ready, notice, exit)
+type controlMessage struct {
+	Type    string `json:"type"`
+	Event   string `json:"event"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+type options struct {
+	server    string
+	token     string
+	cluster   string
+	namespace string
+	pod       string
+	port      int
+	address   string
+	local     int
+	insecure  bool
+}
+
+func forwardURL(o options) (string, error) {
+	u, err := url.Parse(o.server)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("unsupported server scheme: %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws/portforward"
+	u.RawQuery = url.Values{
+		"clusterId": {o.cluster},
+		"namespace": {o.namespace},
+		"pod":       {o.pod},
+		"port":      {strconv.Itoa(o.port)},
+	}.Encode()
+	return u.String(), nil
+}
+
+// 연결 하나당 websocket 하나. 서버의 ready 메시지를 받은 뒤 양방향 복사
+func relay(ctx context.Context, local net.Conn, dialer *websocket.Dialer, target, token string) error {
+	defer local.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	ws, resp, err := dialer.DialContext(ctx, target, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("%w (%s: %s)", err, resp.Status, strings.TrimSpace(string(body)))
+		}
+		return err
+	}
+	defer ws.Close()
+
+	ready, err := readControl(ws)
+	if err != nil {
+		return err
+	}
+	if ready.Type != "portforward" || ready.Event != "ready" {
+		return fmt.Errorf("port forward failed: %s %s", ready.Reason, ready.Message)
+	}
+
+	// 로컬 → 서버. 쓰기는 이 고루틴에서만 수행
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := local.Read(buf)
+			if n > 0 {
+				if werr := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				// 로컬 쪽 쓰기 종료(half-close)를 빈 바이너리 프레임으로 전달. 응답은 서버가 exit 를 보낼 때까지 계속 수신
+				ws.WriteMessage(websocket.BinaryMessage, []byte{})
+				return
+			}
+		}
+	}()
+
+	// 서버 → 로컬
+	for {
+		msgType, msg, err := ws.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+		if msgType == websocket.BinaryMessage {
+			if _, err := local.Write(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		var control controlMessage
+		if err := json.Unmarshal(msg, &control); err != nil {
+			continue
+		}
+		switch {
+		case control.Type == "exit" && control.Reason != "Completed":
+			return fmt.Errorf("port forward ended: %s %s", control.Reason, control.Message)
+		case control.Type == "exit":
+			return nil
+		case control.Message != "":
+			log.Printf("[cp-remote-api] %s", control.Message)
+		}
+	}
+}
+
+func readControl(ws *websocket.Conn) (controlMessage, error) {
+	var control controlMessage
+	msgType, msg, err := ws.ReadMessage()
+	if err != nil {
+		return control, err
+	}
+	if msgType != websocket.TextMessage {
+		return control, errors.New("unexpected data before port forward was ready")
+	}
+	if err := json.Unmarshal(msg, &control); err != nil {
+		return control, fmt.Errorf("unexpected message: %s", msg)
+	}
+	return control, nil
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.server, "server", os.Getenv("CP_REMOTE_API_SERVER"), "cp-remote-api base URL")
+	flag.StringVar(&o.token, "token", os.Getenv("CP_REMOTE_API_TOKEN"), "JWT access token")
+	flag.StringVar(&o.cluster, "cluster", "", "cluster ID")
+	flag.StringVar(&o.namespace, "namespace", "", "pod namespace")
+	flag.StringVar(&o.pod, "pod", "", "pod name")
+	flag.IntVar(&o.port, "port", 0, "container port")
+	flag.StringVar(&o.address, "address", "127.0.0.1", "local listen address")
+	flag.IntVar(&o.local, "local", 0, "local port (default: same as -port)")
+	flag.BoolVar(&o.insecure, "insecure", false, "skip TLS certificate verification")
+	flag.Parse()
+
+	if o.server == "" || o.token == "" || o.cluster == "" || o.namespace == "" || o.pod == "" || o.port == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if o.local == 0 {
+		o.local = o.port
+	}
+	target, err := forwardURL(o)
+	if err != nil {
+		log.Fatalf("invalid server: %v", err)
+	}
+	dialer := &websocket.Dialer{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: o.insecure},
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(o.address, strconv.Itoa(o.local)))
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+	log.Printf("Forwarding from %s -> %s/%s:%d", listener.Addr(), o.namespace, o.pod, o.port)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Fatalf("accept: %v", err)
+		}
+		log.Printf("Handling connection from %s", local.RemoteAddr())
+		go func() {
+			if err := relay(ctx, local, dialer, target, o.token); err != nil {
+				log.Printf("connection from %s: %v", local.RemoteAddr(), err)
+			}
+		}()
+	}
+}
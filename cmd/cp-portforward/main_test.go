@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardURL(t *testing.T) {
+	target, err := forwardURL(options{server: "https://api.example.com/remote/", cluster: "c1", namespace: "ns1", pod: "app-0", port: 5005})
+	require.NoError(t, err)
+	assert.Equal(t, "wss://api.example.com/remote/ws/portforward?clusterId=c1&namespace=ns1&pod=app-0&port=5005", target)
+
+	_, err = forwardURL(options{server: "ftp://api.example.com"})
+	assert.Error(t, err)
+}
+
+func TestRelay(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	newServer := func(t *testing.T, handle func(ws *websocket.Conn)) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			ws, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer ws.Close()
+			handle(ws)
+		}))
+		t.Cleanup(server.Close)
+		return strings.Replace(server.URL, "http", "ws", 1)
+	}
+
+	t.Run("Success - Echo", func(t *testing.T) {
+		target := newServer(t, func(ws *websocket.Conn) {
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"portforward","event":"ready","port":5005}`))
+			_, msg, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(websocket.BinaryMessage, append([]byte("echo:"), msg...))
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"exit","reason":"Completed","message":"connection closed by container"}`))
+			ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		})
+
+		local, client := net.Pipe()
+		done := make(chan error, 1)
+		go func() { done <- relay(context.Background(), local, websocket.DefaultDialer, target, "token") }()
+
+		_, err := client.Write([]byte("ping"))
+		require.NoError(t, err)
+		buf := make([]byte, 64)
+		n, err := client.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "echo:ping", string(buf[:n]))
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("Success - Local half-close keeps response", func(t *testing.T) {
+		target := newServer(t, func(ws *websocket.Conn) {
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"portforward","event":"ready","port":5005}`))
+			var request []byte
+			for {
+				msgType, msg, err := ws.ReadMessage()
+				if !assert.NoError(t, err) || !assert.Equal(t, websocket.BinaryMessage, msgType) {
+					return
+				}
+				if len(msg) == 0 {
+					break
+				}
+				request = append(request, msg...)
+			}
+			ws.WriteMessage(websocket.BinaryMessage, append([]byte("reply:"), request...))
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"exit","reason":"Completed","message":"connection closed by container"}`))
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		done := make(chan error, 1)
+		go func() {
+			local, err := listener.Accept()
+			if err != nil {
+				done <- err
+				return
+			}
+			done <- relay(context.Background(), local, websocket.DefaultDialer, target, "token")
+		}()
+
+		client, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer client.Close()
+		_, err = client.Write([]byte("request"))
+		require.NoError(t, err)
+		require.NoError(t, client.(*net.TCPConn).CloseWrite())
+
+		response, err := io.ReadAll(client)
+		require.NoError(t, err)
+		assert.Equal(t, "reply:request", string(response))
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("Failure - Server rejects port forward", func(t *testing.T) {
+		target := newServer(t, func(ws *websocket.Conn) {
+			ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"exit","reason":"ContainerNotRunning","message":"pod app-0 is not running (phase Pending)"}`))
+		})
+
+		local, _ := net.Pipe()
+		err := relay(context.Background(), local, websocket.DefaultDialer, target, "token")
+		assert.EqualError(t, err, "port forward failed: ContainerNotRunning pod app-0 is not running (phase Pending)")
+	})
+}
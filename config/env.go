@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -19,6 +20,51 @@ type EnvConfigs struct {
 	VaultUrl      string `mapstructure:"VAULT_URL"`
 	VaultRoleId   string `mapstructure:"VAULT_ROLE_ID"`
 	VaultSecretId string `mapstructure:"VAULT_SECRET_ID"`
+	ExecShells    string `mapstructure:"EXEC_SHELLS"`
+
+	ExecCommandTimeoutSeconds   int `mapstructure:"EXEC_COMMAND_TIMEOUT_SECONDS"`
+	ExecCommandOutputLimitBytes int `mapstructure:"EXEC_COMMAND_OUTPUT_LIMIT_BYTES"`
+
+	RecordingEnabled   bool   `mapstructure:"RECORDING_ENABLED"`
+	RecordingDir       string `mapstructure:"RECORDING_DIR"`
+	RecordingStdin     bool   `mapstructure:"RECORDING_STDIN"`
+	RecordingClusters  string `mapstructure:"RECORDING_CLUSTERS"`
+	RecordingUserTypes string `mapstructure:"RECORDING_USER_TYPES"`
+
+	SessionIdleTimeoutMinutes    int    `mapstructure:"SESSION_IDLE_TIMEOUT_MINUTES"`
+	SessionIdleTimeoutByUserType string `mapstructure:"SESSION_IDLE_TIMEOUT_BY_USER_TYPE"`
+	SessionIdleTimeoutByCluster  string `mapstructure:"SESSION_IDLE_TIMEOUT_BY_CLUSTER"`
+	SessionMaxDurationMinutes    int    `mapstructure:"SESSION_MAX_DURATION_MINUTES"`
+	SessionMaxDurationByUserType string `mapstructure:"SESSION_MAX_DURATION_BY_USER_TYPE"`
+	SessionMaxDurationByCluster  string `mapstructure:"SESSION_MAX_DURATION_BY_CLUSTER"`
+	SessionTimeoutWarningSeconds int    `mapstructure:"SESSION_TIMEOUT_WARNING_SECONDS"`
+
+	AuditLogFile string `mapstructure:"AUDIT_LOG_FILE"`
+
+	WsPingIntervalSeconds int `mapstructure:"WS_PING_INTERVAL_SECONDS"`
+	WsPongWaitSeconds     int `mapstructure:"WS_PONG_WAIT_SECONDS"`
+	WsWriteQueueBytes     int `mapstructure:"WS_WRITE_QUEUE_BYTES"`
+	WsWriteTimeoutSeconds int `mapstructure:"WS_WRITE_TIMEOUT_SECONDS"`
+
+	ShutdownGraceSeconds int `mapstructure:"SHUTDOWN_GRACE_SECONDS"`
+
+	DebugImages              string `mapstructure:"DEBUG_IMAGES"`
+	DebugUserTypes           string `mapstructure:"DEBUG_USER_TYPES"`
+	DebugStartTimeoutSeconds int    `mapstructure:"DEBUG_START_TIMEOUT_SECONDS"`
+
+	NodeDebugNamespace string `mapstructure:"NODE_DEBUG_NAMESPACE"`
+	NodeDebugImage     string `mapstructure:"NODE_DEBUG_IMAGE"`
+
+	LogMaxStreams int `mapstructure:"LOG_MAX_STREAMS"`
+
+	FileDownloadMaxBytes int64 `mapstructure:"FILE_DOWNLOAD_MAX_BYTES"`
+	FileUploadMaxBytes   int64 `mapstructure:"FILE_UPLOAD_MAX_BYTES"`
+	FileEditMaxBytes     int64 `mapstructure:"FILE_EDIT_MAX_BYTES"`
+
+	TransferChunkBytes int64 `mapstructure:"TRANSFER_CHUNK_BYTES"`
+	TransferTTLSeconds int   `mapstructure:"TRANSFER_TTL_SECONDS"`
+
+	ProxyTokenTTLSeconds int `mapstructure:"PROXY_TOKEN_TTL_SECONDS"`
 }
 
 func loadEnvVariables() (config *EnvConfigs) {
@@ -36,3 +82,14 @@ func loadEnvVariables() (config *EnvConfigs) {
 	}
 	return
 }
+
+// 쉼표로 구분된 설정값을 목록으로 변환
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"cp-remote-access-api/internal/vault"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CLUSTER_ADMIN 자격 증명(secret/data/user/<guid>/<cluster>) 보유 여부
+var hasClusterAdminCredential = func(clusterID string, userAuthId string) bool {
+	vaultClient, err := vault.NewClient(vault.ConfigFromEnv())
+	if err != nil {
+		log.Printf("Vault 클라이언트 생성 실패: %v", err)
+		return false
+	}
+	if _, err := vaultClient.GetClusterToken(clusterID, userAuthId, "CLUSTER_ADMIN", ""); err != nil {
+		log.Printf("클러스터 관리 권한 없음 (%s, %s): %v", userAuthId, clusterID, err)
+		return false
+	}
+	return true
+}
+
+// 관리자 API 의 조회/제어 범위. SUPER_ADMIN 은 전체, 그 외에는 자격 증명이 있는 클러스터만 허용
+type clusterScope struct {
+	all     bool
+	user    string
+	checked map[string]bool
+}
+
+func adminClusterScope(c *gin.Context) *clusterScope {
+	scope := &clusterScope{checked: map[string]bool{}}
+	val, _ := c.Get("claims")
+	if claims, ok := val.(jwt.MapClaims); ok {
+		userType, _ := claims["userType"].(string)
+		scope.all = userType == "SUPER_ADMIN"
+		scope.user, _ = claims["userAuthId"].(string)
+	}
+	return scope
+}
+
+func (s *clusterScope) allows(clusterID string) bool {
+	if s.all {
+		return true
+	}
+	if s.user == "" || clusterID == "" {
+		return false
+	}
+	allowed, ok := s.checked[clusterID]
+	if !ok {
+		allowed = hasClusterAdminCredential(clusterID, s.user)
+		s.checked[clusterID] = allowed
+	}
+	return allowed
+}
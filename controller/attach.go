@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const controlTypeAttach = "attach"
+
+// attach 시작 시 클라이언트에 전달하는 모드 정보 (stdin 이 없으면 출력만 표시)
+type attachMessage struct {
+	Type      string `json:"type"`
+	Container string `json:"container"`
+	Stdin     bool   `json:"stdin"`
+	Tty       bool   `json:"tty"`
+}
+
+var newAttachExecutor = func(clientset kubernetes.Interface, cfg *rest.Config, pod, namespace string, options *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+	req := clientset.CoreV1().RESTClient().
+		Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("attach").
+		VersionedParams(options, scheme.ParameterCodec)
+
+	return remotecommand.NewWebSocketExecutor(cfg, "POST", req.URL().String())
+}
+
+// 컨테이너 spec 의 stdin/tty 설정에 맞춘 attach 옵션. container 가 비어 있으면 첫 번째 컨테이너
+func attachOptionsFor(pod *corev1.Pod, container string) (*corev1.PodAttachOptions, error) {
+	for _, spec := range pod.Spec.Containers {
+		if container != "" && spec.Name != container {
+			continue
+		}
+		return &corev1.PodAttachOptions{
+			Container: spec.Name,
+			Stdin:     spec.Stdin,
+			Stdout:    true,
+			// TTY 모드에서는 stderr 가 stdout 으로 합쳐짐
+			Stderr: !spec.TTY,
+			TTY:    spec.TTY,
+		}, nil
+	}
+	return nil, fmt.Errorf("container not found (%q)", container)
+}
+
+func AttachWebSocketHandler(c *gin.Context) {
+	var pod = c.Query("pod")
+	var namespace = c.Query("namespace")
+	var container = c.Query("container")
+	var clusterId = c.Query("clusterId")
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Failed to create clientset"))
+		return
+	}
+
+	wsStream := newWebSocketStream(conn)
+	initialSize, hasInitialSize := terminalSizeFromQuery(c)
+	if hasInitialSize {
+		wsStream.resize(initialSize)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, container, time.Now(), err))
+		return
+	}
+	options, err := attachOptionsFor(podInfo, container)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, container, time.Now(), err))
+		return
+	}
+	wsStream.writeJSON(attachMessage{Type: controlTypeAttach, Container: options.Container, Stdin: options.Stdin, Tty: options.TTY})
+
+	executor, err := newAttachExecutor(clientset, cfg, pod, namespace, options)
+	if err != nil {
+		wsStream.writeText("Executor error:" + err.Error())
+		wsStream.close(websocket.CloseInternalServerErr, "executor error")
+		return
+	}
+
+	target := newTerminalTarget(c, claims, sessionKindAttach)
+	target.Container = options.Container
+	runTerminalSession(c.Request.Context(), wsStream, clientset, target, initialSize, executor, terminalMode{Stdin: options.Stdin, Tty: options.TTY})
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/model"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// --- [ AttachWebSocketHandler 테스트 ] ---
+
+func TestAttachOptionsFor(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "app", Stdin: true, TTY: true},
+		{Name: "sidecar"},
+	}}}
+
+	options, err := attachOptionsFor(pod, "")
+	require.NoError(t, err)
+	assert.Equal(t, &corev1.PodAttachOptions{Container: "app", Stdin: true, Stdout: true, Stderr: false, TTY: true}, options)
+
+	options, err = attachOptionsFor(pod, "sidecar")
+	require.NoError(t, err)
+	assert.Equal(t, &corev1.PodAttachOptions{Container: "sidecar", Stdin: false, Stdout: true, Stderr: true, TTY: false}, options)
+
+	_, err = attachOptionsFor(pod, "missing")
+	assert.True(t, isContainerNotRunningError(err))
+}
+
+func TestAttachWebSocketHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "repl", Namespace: "ns1"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Stdin: true, TTY: true},
+			{Name: "worker"},
+		}},
+	})}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/ws/attach", AttachWebSocketHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	dial := func(t *testing.T, query string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/ws/attach"+query, nil)
+		require.NoError(t, err)
+		return conn
+	}
+
+	t.Run("Success - Interactive TTY container", func(t *testing.T) {
+		monkey.Patch(newAttachExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+			assert.Equal(t, "app", opts.Container)
+			return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+				assert.True(t, options.Tty)
+				require.NotNil(t, options.Stdin)
+				require.NotNil(t, options.TerminalSizeQueue)
+				buf := make([]byte, 64)
+				n, err := options.Stdin.Read(buf)
+				require.NoError(t, err)
+				options.Stdout.Write(buf[:n])
+				return nil
+			}}, nil
+		})
+
+		conn := dial(t, "?pod=repl&namespace=ns1&clusterId=c1")
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"attach","container":"app","stdin":true,"tty":true}`, string(msg))
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("1+1\r")))
+		_, msg, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "1+1\r", string(msg))
+
+		_, msg, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Completed"`)
+	})
+
+	t.Run("Success - Output only container", func(t *testing.T) {
+		monkey.Patch(newAttachExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+			return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+				assert.False(t, options.Tty)
+				assert.Nil(t, options.Stdin)
+				assert.Nil(t, options.TerminalSizeQueue)
+				// 클라이언트 입력이 버려지는 동안 출력은 계속 전달
+				time.Sleep(50 * time.Millisecond)
+				options.Stdout.Write([]byte("log line"))
+				options.Stderr.Write([]byte("warn line"))
+				return nil
+			}}, nil
+		})
+
+		conn := dial(t, "?pod=repl&namespace=ns1&container=worker&clusterId=c1")
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"attach","container":"worker","stdin":false,"tty":false}`, string(msg))
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ignored")))
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ignored")))
+
+		var out []string
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				break
+			}
+			out = append(out, string(msg))
+		}
+		require.Len(t, out, 3)
+		assert.Equal(t, "log line", out[0])
+		assert.Equal(t, "warn line", out[1])
+		assert.Contains(t, out[2], `"reason":"Completed"`)
+	})
+
+	t.Run("Failure - Pod not found", func(t *testing.T) {
+		conn := dial(t, "?pod=missing&namespace=ns1&clusterId=c1")
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"PodNotFound"`)
+
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, closeCodePodNotFound, closeErr.Code)
+	})
+}
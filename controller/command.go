@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	defaultCommandTimeout     = 30 * time.Second
+	defaultCommandOutputLimit = 1 << 20
+)
+
+type ExecCommandRequest struct {
+	Command        []string `json:"command"`
+	Stdin          *string  `json:"stdin,omitempty"`
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"`
+}
+
+type ExecCommandResponse struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   *int   `json:"exitCode"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Truncated  bool   `json:"truncated"`
+}
+
+// 설정된 제한까지만 보관하고 나머지는 버리는 Writer
+type limitedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - len(b.buf); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf = append(b.buf, p[:remaining]...)
+		}
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
+
+func commandTimeout(requestedSeconds int) time.Duration {
+	timeout := defaultCommandTimeout
+	if config.Env != nil && config.Env.ExecCommandTimeoutSeconds > 0 {
+		timeout = time.Duration(config.Env.ExecCommandTimeoutSeconds) * time.Second
+	}
+	if requested := time.Duration(requestedSeconds) * time.Second; requested > 0 && requested < timeout {
+		return requested
+	}
+	return timeout
+}
+
+func commandOutputLimit() int {
+	if config.Env != nil && config.Env.ExecCommandOutputLimitBytes > 0 {
+		return config.Env.ExecCommandOutputLimitBytes
+	}
+	return defaultCommandOutputLimit
+}
+
+// 단발성 명령 실행 (TTY 없음). stdout/stderr 는 각각 출력 제한까지 수집
+func ExecCommandHandler(c *gin.Context) {
+	var pod = c.Query("pod")
+	var namespace = c.Query("namespace")
+	var container = c.Query("container")
+	var clusterId = c.Query("clusterId")
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Claims not found in context"})
+		return
+	}
+	claims, exists := val.(jwt.MapClaims)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims format"})
+		return
+	}
+
+	var request ExecCommandRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if len(request.Command) == 0 || strings.TrimSpace(request.Command[0]) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "command is required"})
+		return
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create clientset: " + err.Error()})
+		return
+	}
+
+	executor, err := newExecutor(clientset, cfg, pod, namespace, &corev1.PodExecOptions{
+		Container: container,
+		Command:   request.Command,
+		Stdin:     request.Stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Executor error: " + err.Error()})
+		return
+	}
+
+	limit := commandOutputLimit()
+	stdout := &limitedBuffer{limit: limit}
+	stderr := &limitedBuffer{limit: limit}
+	options := remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr}
+	if request.Stdin != nil {
+		options.Stdin = strings.NewReader(*request.Stdin)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), commandTimeout(request.TimeoutSeconds))
+	defer cancel()
+
+	startedAt := time.Now()
+	err = executor.StreamWithContext(ctx, options)
+	duration := time.Since(startedAt)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = context.DeadlineExceeded
+	}
+
+	exit := describeExecExit(clientset, namespace, pod, container, startedAt, err)
+	c.JSON(int(exit.httpCode()), ExecCommandResponse{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		ExitCode:   exit.ExitCode,
+		Reason:     exit.Reason,
+		Message:    exit.Message,
+		DurationMs: duration.Milliseconds(),
+		Truncated:  stdout.truncated || stderr.truncated,
+	})
+}
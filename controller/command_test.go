@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+type contextExecutor struct {
+	StreamFunc func(ctx context.Context, options remotecommand.StreamOptions) error
+}
+
+func (e *contextExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *contextExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	return e.StreamFunc(ctx, options)
+}
+
+// --- [ ExecCommandHandler 테스트 (REST) ] ---
+
+func TestExecCommandHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name           string
+		env            *config.EnvConfigs
+		body           string
+		withoutClaims  bool
+		stream         func(ctx context.Context, opts *corev1.PodExecOptions, options remotecommand.StreamOptions) error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success - Runs command with stdin",
+			body: `{"command":["cat"],"stdin":"hello"}`,
+			stream: func(ctx context.Context, opts *corev1.PodExecOptions, options remotecommand.StreamOptions) error {
+				if opts.TTY || !opts.Stdin {
+					return errors.New("unexpected exec options")
+				}
+				io.Copy(options.Stdout, options.Stdin)
+				options.Stderr.Write([]byte("warn"))
+				return nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"stdout":"hello","stderr":"warn","exitCode":0,"reason":"Completed","message":"process exited with 0","durationMs":0,"truncated":false}`,
+		},
+		{
+			name: "Success - Output truncated at limit",
+			env:  &config.EnvConfigs{ExecCommandOutputLimitBytes: 4},
+			body: `{"command":["echo","abcdefgh"]}`,
+			stream: func(ctx context.Context, opts *corev1.PodExecOptions, options remotecommand.StreamOptions) error {
+				if opts.Stdin || options.Stdin != nil {
+					return errors.New("stdin should not be attached")
+				}
+				options.Stdout.Write([]byte("abc"))
+				options.Stdout.Write([]byte("defgh"))
+				return nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"stdout":"abcd","stderr":"","exitCode":0,"reason":"Completed","message":"process exited with 0","durationMs":0,"truncated":true}`,
+		},
+		{
+			name: "Success - Non-zero exit code",
+			body: `{"command":["false"]}`,
+			stream: func(ctx context.Context, opts *corev1.PodExecOptions, options remotecommand.StreamOptions) error {
+				return utilexec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 1}
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"stdout":"","stderr":"","exitCode":1,"reason":"NonZeroExitCode","message":"process exited with 1","durationMs":0,"truncated":false}`,
+		},
+		{
+			name: "Failure - Timeout",
+			body: `{"command":["sleep","10"],"timeoutSeconds":1}`,
+			stream: func(ctx context.Context, opts *corev1.PodExecOptions, options remotecommand.StreamOptions) error {
+				options.Stdout.Write([]byte("partial"))
+				<-ctx.Done()
+				return errors.New("stream closed")
+			},
+			expectedStatus: http.StatusRequestTimeout,
+			expectedBody:   `{"stdout":"partial","stderr":"","exitCode":null,"reason":"Timeout","message":"context deadline exceeded","durationMs":0,"truncated":false}`,
+		},
+		{
+			name:           "Failure - Empty command",
+			body:           `{"command":[]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"command is required"}`,
+		},
+		{
+			name:           "Failure - No Claims",
+			body:           `{"command":["ls"]}`,
+			withoutClaims:  true,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"Claims not found in context"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(monkey.UnpatchAll)
+			monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+			config.Env = tc.env
+			if config.Env == nil {
+				config.Env = &config.EnvConfigs{}
+			}
+
+			monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+				return model.ClusterCredential{BearerToken: "token"}, nil
+			})
+			K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+			monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+				return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+					return tc.stream(ctx, opts, options)
+				}}, nil
+			})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/exec?pod=p1&namespace=ns1&container=c1&clusterId=c1", bytes.NewBufferString(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if !tc.withoutClaims {
+				c.Set("claims", jwt.MapClaims{"userAuthId": "u1", "userType": "USER"})
+			}
+
+			ExecCommandHandler(c)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			body := w.Body.String()
+			if tc.expectedStatus != http.StatusBadRequest && tc.expectedStatus != http.StatusUnauthorized {
+				// durationMs 는 실행 환경에 따라 달라지므로 0 으로 정규화
+				var resp ExecCommandResponse
+				require.NoError(t, json.Unmarshal([]byte(body), &resp))
+				if tc.name == "Failure - Timeout" {
+					assert.GreaterOrEqual(t, resp.DurationMs, (900 * time.Millisecond).Milliseconds())
+				}
+				resp.DurationMs = 0
+				normalized, _ := json.Marshal(resp)
+				body = string(normalized)
+			}
+			assert.JSONEq(t, tc.expectedBody, body)
+		})
+	}
+}
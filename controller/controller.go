@@ -7,6 +7,7 @@ import (
 	"cp-remote-access-api/model"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -43,21 +44,14 @@ func GetClusterInfo(clusterID string, userAuthId string, userType string, namesp
 	return clusterInfo, nil
 }
 
-var newExecutor = func(clientset kubernetes.Interface, cfg *rest.Config, pod, namespace, container string) (remotecommand.Executor, error) {
+var newExecutor = func(clientset kubernetes.Interface, cfg *rest.Config, pod, namespace string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
 	req := clientset.CoreV1().RESTClient().
 		Post().
 		Resource("pods").
 		Name(pod).
 		Namespace(namespace).
 		SubResource("exec").
-		VersionedParams(&corev1.PodExecOptions{
-			Container: container,
-			Command:   []string{"/bin/sh"},
-			Stdin:     true,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       true,
-		}, scheme.ParameterCodec)
+		VersionedParams(options, scheme.ParameterCodec)
 
 	return remotecommand.NewWebSocketExecutor(cfg, "POST", req.URL().String())
 }
@@ -67,6 +61,7 @@ func ExecWebSocketHandler(c *gin.Context) {
 	var namespace = c.Query("namespace")
 	var container = c.Query("container")
 	var clusterId = c.Query("clusterId")
+	var requestedShell = c.Query("shell")
 
 	val, exists := c.Get("claims")
 	if !exists {
@@ -74,6 +69,12 @@ func ExecWebSocketHandler(c *gin.Context) {
 		return
 	}
 
+	shell, err := resolveShell(requestedShell)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	claims := val.(jwt.MapClaims)
 
 	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
@@ -88,8 +89,6 @@ func ExecWebSocketHandler(c *gin.Context) {
 			Insecure: true,
 		},
 	}
-	Upgrader.Subprotocols = []string{"bearer"}
-
 	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Upgrade error: %v", err)
@@ -103,23 +102,33 @@ func ExecWebSocketHandler(c *gin.Context) {
 		return
 	}
 
-	executor, err := newExecutor(clientset, cfg, pod, namespace, container)
-	if err != nil {
-		conn.WriteMessage(websocket.TextMessage, []byte("Executor error:"+err.Error()))
-		return
+	wsStream := newWebSocketStream(conn)
+	initialSize, hasInitialSize := terminalSizeFromQuery(c)
+	if hasInitialSize {
+		wsStream.resize(initialSize)
 	}
 
-	wsStream := newWebSocketStream(conn)
+	if shell == shellAuto {
+		shell, err = detectShell(clientset, cfg, pod, namespace, container)
+		if err != nil {
+			wsStream.writeExit(describeExecExit(clientset, namespace, pod, container, time.Now(), err))
+			return
+		}
+	}
+	if requestedShell != "" {
+		wsStream.writeJSON(shellMessage{Type: controlTypeShell, Shell: shell})
+	}
 
-	err = executor.Stream(remotecommand.StreamOptions{
-		Stdin:  wsStream,
-		Stdout: wsStream,
-		Stderr: wsStream,
-		Tty:    true,
-	})
+	executor, err := newExecutor(clientset, cfg, pod, namespace, shellExecOptions(container, shell))
 	if err != nil {
-		conn.WriteMessage(websocket.TextMessage, []byte("Exec stream error: "+err.Error()))
+		wsStream.writeText("Executor error:" + err.Error())
+		wsStream.close(websocket.CloseInternalServerErr, "executor error")
+		return
 	}
+
+	target := newTerminalTarget(c, claims, sessionKindExec)
+	target.Shell = shell
+	runTerminalSession(c.Request.Context(), wsStream, clientset, target, initialSize, executor, terminalMode{Stdin: true, Tty: true})
 }
 
 type ContainerShellStatus struct {
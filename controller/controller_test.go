@@ -6,16 +6,21 @@ import (
 	"cp-remote-access-api/internal/vault"
 	"cp-remote-access-api/model"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bouk/monkey"
 	"github.com/gin-gonic/gin"
@@ -25,12 +30,14 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	fakecorev1 "k8s.io/client-go/kubernetes/typed/core/v1/fake"
 	"k8s.io/client-go/rest"
 	fakerest "k8s.io/client-go/rest/fake"
 	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
 )
 
 // --- [ 1. 헬퍼 Structs & Funcs (K8s) ] ---
@@ -339,7 +346,7 @@ func TestExecWebSocketHandler(t *testing.T) {
 		})
 		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
 
-		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n, co string) (remotecommand.Executor, error) {
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
 			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
 				options.Stdout.Write([]byte("hello from server"))
 				time.Sleep(50 * time.Millisecond)
@@ -356,6 +363,113 @@ func TestExecWebSocketHandler(t *testing.T) {
 		assert.Equal(t, "hello from server", string(msg))
 	})
 
+	t.Run("Success - Initial terminal size from query", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		server := setupTestServer(t)
+		defer server.Close()
+
+		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+			return model.ClusterCredential{BearerToken: "token"}, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+				size := options.TerminalSizeQueue.Next()
+				options.Stdout.Write([]byte(fmt.Sprintf("%dx%d", size.Width, size.Height)))
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			}}, nil
+		})
+
+		clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1&cols=132&rows=43")
+		defer clientConn.Close()
+		_, msg, err := clientConn.ReadMessage()
+
+		require.NoError(t, err)
+		assert.Equal(t, "132x43", string(msg))
+	})
+
+	t.Run("Success - Channel protocol multiplexing", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		server := setupTestServer(t)
+		defer server.Close()
+
+		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+			return model.ClusterCredential{BearerToken: "token"}, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+				size := options.TerminalSizeQueue.Next()
+				stdin, _ := io.ReadAll(options.Stdin)
+				options.Stdout.Write([]byte(fmt.Sprintf("%s %dx%d", stdin, size.Width, size.Height)))
+				options.Stderr.Write([]byte("oops"))
+				return nil
+			}}, nil
+		})
+
+		wsURL := strings.Replace(server.URL, "http", "ws", 1) + "/ws/exec?clusterId=c1"
+		dialer := websocket.Dialer{Subprotocols: []string{"v5.channel.k8s.io"}}
+		clientConn, _, err := dialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer clientConn.Close()
+		assert.Equal(t, "v5.channel.k8s.io", clientConn.Subprotocol())
+
+		require.NoError(t, clientConn.WriteMessage(websocket.BinaryMessage, []byte("\x04{\"Width\":100,\"Height\":30}")))
+		require.NoError(t, clientConn.WriteMessage(websocket.BinaryMessage, []byte("\x00echo")))
+		require.NoError(t, clientConn.WriteMessage(websocket.BinaryMessage, []byte{255, 0}))
+
+		readFrame := func() []byte {
+			msgType, msg, err := clientConn.ReadMessage()
+			require.NoError(t, err)
+			assert.Equal(t, websocket.BinaryMessage, msgType)
+			return msg
+		}
+		assert.Equal(t, "\x01echo 100x30", string(readFrame()))
+		assert.Equal(t, "\x02oops", string(readFrame()))
+		status := readFrame()
+		assert.Equal(t, byte(3), status[0])
+		assert.JSONEq(t, `{"metadata":{},"status":"Success"}`, string(status[1:]))
+	})
+
+	t.Run("Success - Session recorded when enabled", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		dir := t.TempDir()
+		config.Env = &config.EnvConfigs{RecordingEnabled: true, RecordingDir: dir}
+		t.Cleanup(func() { config.Env = &config.EnvConfigs{} })
+		server := setupTestServer(t)
+		defer server.Close()
+
+		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+			return model.ClusterCredential{BearerToken: "token"}, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+				options.Stdout.Write([]byte("recorded output"))
+				return nil
+			}}, nil
+		})
+
+		clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+		defer clientConn.Close()
+		for {
+			if _, _, err := clientConn.ReadMessage(); err != nil {
+				break
+			}
+		}
+
+		casts, err := filepath.Glob(filepath.Join(dir, "*.cast"))
+		require.NoError(t, err)
+		require.Len(t, casts, 1)
+		content, err := os.ReadFile(casts[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"user":"ws-user"`)
+		assert.Contains(t, string(content), `"o","recorded output"`)
+	})
+
 	t.Run("Failure - GetClusterInfo fails (before upgrade)", func(t *testing.T) {
 		t.Cleanup(monkey.UnpatchAll)
 		w := httptest.NewRecorder()
@@ -402,7 +516,7 @@ func TestExecWebSocketHandler(t *testing.T) {
 			return model.ClusterCredential{BearerToken: "token"}, nil
 		})
 		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
-		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n, co string) (remotecommand.Executor, error) {
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
 			return nil, errors.New("executor fail")
 		})
 		monkey.Patch((*fakecorev1.FakeCoreV1).RESTClient, func(*fakecorev1.FakeCoreV1) rest.Interface { return &fakerest.RESTClient{} })
@@ -415,30 +529,94 @@ func TestExecWebSocketHandler(t *testing.T) {
 		assert.Contains(t, string(msg), "Executor error:executor fail")
 	})
 
-	t.Run("Failure - Stream fails", func(t *testing.T) {
-		t.Cleanup(monkey.UnpatchAll)
-		K8sClientFactoryImpl = &realK8sClientFactory{}
-		server := setupTestServer(t)
-		defer server.Close()
-
-		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
-			return model.ClusterCredential{BearerToken: "token"}, nil
-		})
-		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
-		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n, co string) (remotecommand.Executor, error) {
-			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
-				return errors.New("stream fail")
-			}}, nil
-		})
-		monkey.Patch((*fakecorev1.FakeCoreV1).RESTClient, func(*fakecorev1.FakeCoreV1) rest.Interface { return &fakerest.RESTClient{} })
+	runningPod := func(containerStatus corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1"},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{containerStatus}},
+		}
+	}
 
-		clientConn := clientDial(t, server.URL, "?clusterId=c1")
-		defer clientConn.Close()
-		_, msg, err := clientConn.ReadMessage()
+	streamExitCases := []struct {
+		name              string
+		objects           []runtime.Object
+		streamErr         error
+		expectedExit      string
+		expectedCloseCode int
+		expectedCloseText string
+	}{
+		{
+			name: "Failure - Stream fails",
+			objects: []runtime.Object{runningPod(corev1.ContainerStatus{
+				Name:  "c1",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			})},
+			streamErr:         errors.New("stream fail"),
+			expectedExit:      `{"type":"exit","reason":"Error","message":"stream fail"}`,
+			expectedCloseCode: websocket.CloseInternalServerErr,
+			expectedCloseText: "stream fail",
+		},
+		{
+			name: "Failure - Non-zero exit code with container termination reason",
+			objects: []runtime.Object{runningPod(corev1.ContainerStatus{
+				Name:  "c1",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Reason:     "OOMKilled",
+					ExitCode:   137,
+					FinishedAt: metav1.NewTime(time.Now().Add(time.Minute)),
+				}},
+			})},
+			streamErr:         utilexec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 137},
+			expectedExit:      `{"type":"exit","exitCode":137,"reason":"NonZeroExitCode","containerReason":"OOMKilled","message":"process exited with 137 (OOMKilled)"}`,
+			expectedCloseCode: websocket.CloseNormalClosure,
+			expectedCloseText: "process exited with 137 (OOMKilled)",
+		},
+		{
+			name:              "Failure - Pod gone during stream",
+			streamErr:         errors.New("websocket: close 1006 (abnormal closure)"),
+			expectedExit:      `{"type":"exit","reason":"PodNotFound","message":"websocket: close 1006 (abnormal closure)"}`,
+			expectedCloseCode: closeCodePodNotFound,
+			expectedCloseText: "websocket: close 1006 (abnormal closure)",
+		},
+		{
+			name:              "Success - Process exited normally",
+			expectedExit:      `{"type":"exit","exitCode":0,"reason":"Completed","message":"process exited with 0"}`,
+			expectedCloseCode: websocket.CloseNormalClosure,
+			expectedCloseText: "process exited with 0",
+		},
+	}
 
-		require.NoError(t, err)
-		assert.Contains(t, string(msg), "Exec stream error: stream fail")
-	})
+	for _, tc := range streamExitCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(monkey.UnpatchAll)
+			K8sClientFactoryImpl = &realK8sClientFactory{}
+			server := setupTestServer(t)
+			defer server.Close()
+
+			monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+				return model.ClusterCredential{BearerToken: "token"}, nil
+			})
+			K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset(tc.objects...)}
+			monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+				return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+					return tc.streamErr
+				}}, nil
+			})
+
+			clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+			defer clientConn.Close()
+			_, msg, err := clientConn.ReadMessage()
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expectedExit, string(msg))
+
+			_, _, err = clientConn.ReadMessage()
+			var closeErr *websocket.CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, tc.expectedCloseCode, closeErr.Code)
+			assert.Equal(t, tc.expectedCloseText, closeErr.Text)
+		})
+	}
 
 	t.Run("Failure - No Claims (before upgrade)", func(t *testing.T) {
 		t.Cleanup(monkey.UnpatchAll)
@@ -474,6 +652,8 @@ func TestWebSocketStream_ReadWrite(t *testing.T) {
 
 		_, err = stream.Write([]byte("hello from server"))
 		require.NoError(t, err, "Server: stream.Write should not fail")
+		// 쓰기는 비동기이므로 연결을 닫기 전에 대기열을 비움
+		stream.close(websocket.CloseNormalClosure, "")
 	}))
 	defer server.Close()
 
@@ -526,3 +706,155 @@ func TestWebSocketStream_ConnectionClosed(t *testing.T) {
 	clientConn.Close()
 	wg.Wait()
 }
+
+func TestWebSocketStream_Resize(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer wg.Done()
+		conn, err := Upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err, "Server: Upgrade should not fail")
+		defer conn.Close()
+
+		stream := newWebSocketStream(conn)
+
+		// resize 제어 메시지는 stdin 으로 전달되지 않고 size queue 로 전달되어야 함
+		size := stream.Next()
+		require.NotNil(t, size)
+		assert.Equal(t, remotecommand.TerminalSize{Width: 120, Height: 40}, *size)
+
+		buffer := make([]byte, 1024)
+		n, err := stream.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, "ls -al\r", string(buffer[:n]))
+
+		// 잘못된 크기는 무시되고, 연결 종료 시 Next 는 nil 을 반환해야 함
+		assert.Nil(t, stream.Next())
+	}))
+	defer server.Close()
+
+	wsURL := strings.Replace(server.URL, "http", "ws", 1)
+	clientConn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err, "Client: Dial should not fail")
+
+	require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`)))
+	require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte("ls -al\r")))
+	require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":0,"rows":40}`)))
+	time.Sleep(50 * time.Millisecond)
+	clientConn.Close()
+	wg.Wait()
+}
+
+func TestWebSocketStream_Keepalive(t *testing.T) {
+	streams := make(chan *webSocketStream, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		streams <- newStream(conn, false, streamLimits{
+			pingInterval:    50 * time.Millisecond,
+			pongWait:        150 * time.Millisecond,
+			writeQueueBytes: defaultWriteQueueBytes,
+			writeTimeout:    time.Second,
+		})
+	}))
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "http", "ws", 1)
+
+	t.Run("Live peer answers pings", func(t *testing.T) {
+		clientConn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer clientConn.Close()
+		stream := <-streams
+
+		// 클라이언트가 읽기를 계속하면 기본 ping 핸들러가 pong 으로 응답
+		var pings atomic.Int32
+		clientConn.SetPingHandler(func(data string) error {
+			pings.Add(1)
+			return clientConn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		go func() {
+			for {
+				if _, _, err := clientConn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-stream.done:
+			t.Fatal("pong 에 응답하는 연결이 끊김")
+		case <-time.After(500 * time.Millisecond):
+		}
+		assert.GreaterOrEqual(t, pings.Load(), int32(3))
+		stream.conn.Close()
+	})
+
+	t.Run("Dead peer is detected", func(t *testing.T) {
+		// 읽지 않는 클라이언트는 pong 을 보내지 않음
+		clientConn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer clientConn.Close()
+		stream := <-streams
+		defer stream.conn.Close()
+
+		select {
+		case <-stream.done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("응답 없는 연결이 감지되지 않음")
+		}
+		_, err = stream.Read(make([]byte, 16))
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("Unread input does not block teardown", func(t *testing.T) {
+		clientConn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer clientConn.Close()
+		stream := <-streams
+		defer stream.conn.Close()
+
+		// 소비자가 없는 입력이 있어도 종료 후 readLoop 는 계속 읽고 연결 끊김을 감지해야 함
+		require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte("ls\r")))
+		stream.close(websocket.CloseNormalClosure, "")
+		require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte("pwd\r")))
+
+		select {
+		case <-stream.done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("readLoop 가 입력 전달에서 멈춤")
+		}
+	})
+}
+
+func TestStreamLimitsFromEnv(t *testing.T) {
+	config.Env = &config.EnvConfigs{}
+	limits := streamLimitsFromEnv()
+	assert.Equal(t, defaultPingInterval, limits.pingInterval)
+	assert.Equal(t, defaultPongWait, limits.pongWait)
+	assert.Equal(t, defaultWriteQueueBytes, limits.writeQueueBytes)
+	assert.Equal(t, defaultWriteTimeout, limits.writeTimeout)
+
+	// ping 주기가 pong 대기 시간 이상이면 절반으로 조정
+	config.Env = &config.EnvConfigs{WsPingIntervalSeconds: 90, WsPongWaitSeconds: 40, WsWriteQueueBytes: 1024, WsWriteTimeoutSeconds: 3}
+	limits = streamLimitsFromEnv()
+	assert.Equal(t, 20*time.Second, limits.pingInterval)
+	assert.Equal(t, 40*time.Second, limits.pongWait)
+	assert.Equal(t, 1024, limits.writeQueueBytes)
+	assert.Equal(t, 3*time.Second, limits.writeTimeout)
+}
+
+// TestExecExitCloseText: close frame reason 은 123 바이트 이내의 유효한 UTF-8
+func TestExecExitCloseText(t *testing.T) {
+	assert.Equal(t, exitReasonCompleted, execExit{Reason: exitReasonCompleted}.closeText())
+
+	// 3바이트 문자 41개 = 123 바이트, 42번째 문자는 잘림
+	message := strings.Repeat("가", 42)
+	text := execExit{Reason: exitReasonError, Message: message}.closeText()
+	assert.Equal(t, strings.Repeat("가", 41), text)
+
+	text = execExit{Reason: exitReasonError, Message: "x" + message}.closeText()
+	assert.Equal(t, "x"+strings.Repeat("가", 40), text)
+	assert.True(t, utf8.ValidString(text))
+	assert.LessOrEqual(t, len(text), 123)
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const sessionKindDebug = "debug"
+
+const (
+	controlTypeDebug   = "debug"
+	debugPhaseCreating = "creating"
+	debugPhaseWaiting  = "waiting"
+	debugPhaseRunning  = "running"
+
+	defaultDebugStartTimeout = 60 * time.Second
+	debugPollInterval        = 500 * time.Millisecond
+
+	auditEventDebugContainer = "debug.container"
+)
+
+var (
+	defaultDebugImages    = []string{"busybox:1.36"}
+	defaultDebugUserTypes = []string{"SUPER_ADMIN", "CLUSTER_ADMIN"}
+)
+
+// 이미지 풀 실패 등으로 시작할 수 없는 상태
+var debugStartFailureReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerError":       true,
+	"CreateContainerConfigError": true,
+}
+
+// 디버그 컨테이너 준비 진행 상황
+type debugMessage struct {
+	Type      string `json:"type"`
+	Phase     string `json:"phase"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Target    string `json:"target,omitempty"`
+}
+
+func debugImages() []string {
+	if config.Env != nil && config.Env.DebugImages != "" {
+		return config.SplitList(config.Env.DebugImages)
+	}
+	return defaultDebugImages
+}
+
+func debugUserTypes() []string {
+	if config.Env != nil && config.Env.DebugUserTypes != "" {
+		return config.SplitList(config.Env.DebugUserTypes)
+	}
+	return defaultDebugUserTypes
+}
+
+func debugStartTimeout() time.Duration {
+	if config.Env != nil && config.Env.DebugStartTimeoutSeconds > 0 {
+		return time.Duration(config.Env.DebugStartTimeoutSeconds) * time.Second
+	}
+	return defaultDebugStartTimeout
+}
+
+// image 파라미터를 허용 목록과 대조. 비어 있으면 첫 번째 이미지
+func resolveDebugImage(requested string) (string, error) {
+	images := debugImages()
+	if requested == "" && len(images) > 0 {
+		return images[0], nil
+	}
+	for _, image := range images {
+		if image == requested {
+			return image, nil
+		}
+	}
+	return "", fmt.Errorf("debug image not allowed: %s", requested)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// 대상 컨테이너의 프로세스 네임스페이스를 공유하는 ephemeral container 추가.
+// 대상을 지정하지 않으면 첫 번째 컨테이너를 사용하며, 실제 대상 컨테이너 이름을 함께 반환.
+// 이미지의 기본 진입점(shell)을 실행하고, 첫 attach 가 끊기면 stdin 이 닫혀 종료됨
+func addDebugContainer(ctx context.Context, clientset kubernetes.Interface, namespace, pod, target, image string) (string, string, error) {
+	podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	if target == "" && len(podInfo.Spec.Containers) > 0 {
+		target = podInfo.Spec.Containers[0].Name
+	}
+	found := false
+	for _, c := range podInfo.Spec.Containers {
+		found = found || c.Name == target
+	}
+	if !found {
+		return "", "", fmt.Errorf("container not found (%q)", target)
+	}
+
+	name := "debugger-" + utilrand.String(5)
+	updated := podInfo.DeepCopy()
+	updated.Spec.EphemeralContainers = append(updated.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			StdinOnce:                true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: target,
+	})
+	if _, err := clientset.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, pod, updated, metav1.UpdateOptions{}); err != nil {
+		return "", "", err
+	}
+	return name, target, nil
+}
+
+// ephemeral container 가 Running 이 될 때까지 대기
+func waitForDebugContainer(ctx context.Context, clientset kubernetes.Interface, namespace, pod, name string) error {
+	var lastReason string
+	err := wait.PollUntilContextTimeout(ctx, debugPollInterval, debugStartTimeout(), true, func(ctx context.Context) (bool, error) {
+		podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range podInfo.Status.EphemeralContainerStatuses {
+			if status.Name != name {
+				continue
+			}
+			switch {
+			case status.State.Running != nil:
+				return true, nil
+			case status.State.Terminated != nil:
+				return false, fmt.Errorf("debug container is not running: %s", status.State.Terminated.Reason)
+			case status.State.Waiting != nil:
+				lastReason = status.State.Waiting.Reason
+				if debugStartFailureReasons[lastReason] {
+					return false, fmt.Errorf("debug container is not running: %s %s", lastReason, status.State.Waiting.Message)
+				}
+			}
+		}
+		return false, nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out waiting for debug container to start (last state %q): %w", lastReason, err)
+	}
+	return err
+}
+
+func DebugWebSocketHandler(c *gin.Context) {
+	var pod = c.Query("pod")
+	var namespace = c.Query("namespace")
+	var container = c.Query("container")
+	var clusterId = c.Query("clusterId")
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+
+	if !containsString(debugUserTypes(), claims["userType"].(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "debug containers are not allowed for this user type"})
+		return
+	}
+	image, err := resolveDebugImage(c.Query("image"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Failed to create clientset"))
+		return
+	}
+
+	wsStream := newWebSocketStream(conn)
+	initialSize, hasInitialSize := terminalSizeFromQuery(c)
+	if hasInitialSize {
+		wsStream.resize(initialSize)
+	}
+
+	progress := debugMessage{Type: controlTypeDebug, Phase: debugPhaseCreating, Image: image, Target: container}
+	wsStream.writeJSON(progress)
+
+	ctx := c.Request.Context()
+	name, targetContainer, err := addDebugContainer(ctx, clientset, namespace, pod, container, image)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, container, time.Now(), err))
+		return
+	}
+	target := newTerminalTarget(c, claims, sessionKindDebug)
+	target.Container = name
+	audit.Log(audit.Event{
+		Type:      auditEventDebugContainer,
+		Kind:      sessionKindDebug,
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Namespace: target.Namespace,
+		Pod:       target.Pod,
+		Container: name,
+		ClientIP:  target.ClientIP,
+		Details:   map[string]interface{}{"image": image, "targetContainer": targetContainer},
+	})
+
+	progress.Phase, progress.Container, progress.Target = debugPhaseWaiting, name, targetContainer
+	wsStream.writeJSON(progress)
+	if err := waitForDebugContainer(ctx, clientset, namespace, pod, name); err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, name, time.Now(), err))
+		return
+	}
+
+	progress.Phase = debugPhaseRunning
+	wsStream.writeJSON(progress)
+
+	// exec 로 shell 을 따로 띄우면 세션이 끝나도 진입점 shell 이 파드에 남으므로 진입점에 직접 attach.
+	// StdinOnce 이므로 세션이 끝나 attach 가 끊기면 stdin 이 닫혀 진입점도 종료됨
+	executor, err := newAttachExecutor(clientset, cfg, pod, namespace, &corev1.PodAttachOptions{
+		Container: name,
+		Stdin:     true,
+		Stdout:    true,
+		TTY:       true,
+	})
+	if err != nil {
+		wsStream.writeText("Executor error:" + err.Error())
+		wsStream.close(websocket.CloseInternalServerErr, "executor error")
+		return
+	}
+	runTerminalSession(ctx, wsStream, clientset, target, initialSize, executor, terminalMode{Stdin: true, Tty: true})
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// --- [ DebugWebSocketHandler 테스트 ] ---
+
+func TestResolveDebugImage(t *testing.T) {
+	config.Env = &config.EnvConfigs{DebugImages: "busybox:1.36, nicolaka/netshoot:v0.13"}
+
+	image, err := resolveDebugImage("")
+	require.NoError(t, err)
+	assert.Equal(t, "busybox:1.36", image)
+
+	image, err = resolveDebugImage("nicolaka/netshoot:v0.13")
+	require.NoError(t, err)
+	assert.Equal(t, "nicolaka/netshoot:v0.13", image)
+
+	_, err = resolveDebugImage("attacker/rootkit:latest")
+	assert.Error(t, err)
+}
+
+// newDebugClientset: ephemeral container 추가 후 지정한 상태를 보고하는 fake clientset
+func newDebugClientset(state corev1.ContainerState) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "distroless", Namespace: "ns1"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+	})
+	var mu sync.Mutex
+	var added *corev1.Pod
+	clientset.PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "ephemeralcontainers" {
+			return false, nil, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		added = action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod).DeepCopy()
+		return true, added, nil
+	})
+	clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if added == nil {
+			return false, nil, nil
+		}
+		pod := added.DeepCopy()
+		for _, ec := range pod.Spec.EphemeralContainers {
+			pod.Status.EphemeralContainerStatuses = append(pod.Status.EphemeralContainerStatuses, corev1.ContainerStatus{Name: ec.Name, State: state})
+		}
+		return true, pod, nil
+	})
+	return clientset
+}
+
+func TestDebugWebSocketHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+
+	var auditMu sync.Mutex
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		events = append(events, event)
+	})
+
+	newServer := func(userType string) *httptest.Server {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("claims", jwt.MapClaims{"userAuthId": "sre-1", "userType": userType})
+			c.Next()
+		})
+		r.GET("/ws/debug", DebugWebSocketHandler)
+		return httptest.NewServer(r)
+	}
+	adminServer := newServer("CLUSTER_ADMIN")
+	defer adminServer.Close()
+	adminURL := strings.Replace(adminServer.URL, "http", "ws", 1) + "/ws/debug"
+
+	t.Run("Failure - User type not allowed", func(t *testing.T) {
+		server := newServer("USER")
+		defer server.Close()
+		resp, err := http.Get(server.URL + "/ws/debug?pod=distroless&namespace=ns1&clusterId=c1")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Failure - Image not allowed", func(t *testing.T) {
+		resp, err := http.Get(adminServer.URL + "/ws/debug?pod=distroless&namespace=ns1&clusterId=c1&image=evil:latest")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Success - Exec into debug container", func(t *testing.T) {
+		clientset := newDebugClientset(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+		var attached *corev1.PodAttachOptions
+		monkey.Patch(newAttachExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+			attached = opts
+			return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+				if options.Tty {
+					options.Stdout.Write([]byte("/ # "))
+				}
+				return nil
+			}}, nil
+		})
+
+		conn, _, err := websocket.DefaultDialer.Dial(adminURL+"?pod=distroless&namespace=ns1&container=app&clusterId=c1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var phases []string
+		var debugContainer string
+		for len(phases) < 3 {
+			var msg debugMessage
+			require.NoError(t, conn.ReadJSON(&msg))
+			assert.Equal(t, "debug", msg.Type)
+			assert.Equal(t, "busybox:1.36", msg.Image)
+			phases = append(phases, msg.Phase)
+			debugContainer = msg.Container
+		}
+		assert.Equal(t, []string{"creating", "waiting", "running"}, phases)
+		assert.True(t, strings.HasPrefix(debugContainer, "debugger-"))
+
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "/ # ", string(msg))
+		_, msg, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Completed"`)
+		// 별도 shell 을 exec 하지 않고 진입점에 attach
+		require.NotNil(t, attached)
+		assert.Equal(t, debugContainer, attached.Container)
+		assert.True(t, attached.Stdin)
+		assert.True(t, attached.TTY)
+
+		// ephemeral container 는 대상 컨테이너의 프로세스 네임스페이스를 공유
+		var update *corev1.Pod
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "ephemeralcontainers" {
+				update = action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod)
+			}
+		}
+		require.NotNil(t, update)
+		require.Len(t, update.Spec.EphemeralContainers, 1)
+		assert.Equal(t, "app", update.Spec.EphemeralContainers[0].TargetContainerName)
+		assert.Equal(t, "busybox:1.36", update.Spec.EphemeralContainers[0].Image)
+		// attach 가 끊기면 진입점이 종료되어 파드에 shell 이 남지 않음
+		assert.True(t, update.Spec.EphemeralContainers[0].StdinOnce)
+
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		require.NotEmpty(t, events)
+		assert.Equal(t, "debug.container", events[0].Type)
+		assert.Equal(t, "app", events[0].Details["targetContainer"])
+	})
+
+	t.Run("Success - Default target container is recorded", func(t *testing.T) {
+		clientset := newDebugClientset(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+		monkey.Patch(newAttachExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodAttachOptions) (remotecommand.Executor, error) {
+			return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+				return nil
+			}}, nil
+		})
+		auditMu.Lock()
+		events = nil
+		auditMu.Unlock()
+
+		conn, _, err := websocket.DefaultDialer.Dial(adminURL+"?pod=distroless&namespace=ns1&clusterId=c1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		targets := map[string]string{}
+		for len(targets) < 3 {
+			var msg debugMessage
+			require.NoError(t, conn.ReadJSON(&msg))
+			targets[msg.Phase] = msg.Target
+		}
+		assert.Equal(t, "", targets[debugPhaseCreating])
+		assert.Equal(t, "app", targets[debugPhaseWaiting])
+		assert.Equal(t, "app", targets[debugPhaseRunning])
+
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		require.NotEmpty(t, events)
+		assert.Equal(t, "debug.container", events[0].Type)
+		assert.Equal(t, "app", events[0].Details["targetContainer"])
+	})
+
+	t.Run("Failure - Debug image cannot be pulled", func(t *testing.T) {
+		clientset := newDebugClientset(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+
+		conn, _, err := websocket.DefaultDialer.Dial(adminURL+"?pod=distroless&namespace=ns1&clusterId=c1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var exit execExit
+		for exit.Type != controlTypeExit {
+			require.NoError(t, conn.ReadJSON(&exit))
+		}
+		assert.Equal(t, exitReasonContainerNotRunning, exit.Reason)
+		assert.Contains(t, exit.Message, "ErrImagePull")
+
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, closeCodeContainerNotRunning, closeErr.Code)
+	})
+}
@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/internal/session"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	exitReasonCompleted           = "Completed"
+	exitReasonNonZeroExitCode     = "NonZeroExitCode"
+	exitReasonPodNotFound         = "PodNotFound"
+	exitReasonContainerNotRunning = "ContainerNotRunning"
+	exitReasonForbidden           = "Forbidden"
+	exitReasonTimeout             = "Timeout"
+	exitReasonShellNotFound       = "ShellNotFound"
+	exitReasonTerminated          = "Terminated"
+	exitReasonIdleTimeout         = session.ReasonIdleTimeout
+	exitReasonMaxDuration         = session.ReasonMaxDurationExceeded
+	exitReasonDisconnected        = "Disconnected"
+	exitReasonServerShutdown      = session.ReasonServerShutdown
+	exitReasonError               = "Error"
+)
+
+// 애플리케이션 정의 close code (4000~4999), 뒤 세 자리는 HTTP 상태 코드에 대응
+const (
+	closeCodeForbidden           = 4403
+	closeCodePodNotFound         = 4404
+	closeCodeTimeout             = 4408
+	closeCodeContainerNotRunning = 4409
+	closeCodeShellNotFound       = 4422
+)
+
+const controlTypeExit = "exit"
+
+// 세션 종료 시 클라이언트로 전달하는 최종 상태
+type execExit struct {
+	Type            string `json:"type"`
+	ExitCode        *int   `json:"exitCode,omitempty"`
+	Reason          string `json:"reason"`
+	ContainerReason string `json:"containerReason,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
+func (e execExit) closeCode() int {
+	switch e.Reason {
+	case exitReasonCompleted, exitReasonNonZeroExitCode:
+		return websocket.CloseNormalClosure
+	case exitReasonForbidden:
+		return closeCodeForbidden
+	case exitReasonPodNotFound:
+		return closeCodePodNotFound
+	case exitReasonTimeout, exitReasonIdleTimeout, exitReasonMaxDuration:
+		return closeCodeTimeout
+	case exitReasonContainerNotRunning:
+		return closeCodeContainerNotRunning
+	case exitReasonShellNotFound:
+		return closeCodeShellNotFound
+	case exitReasonTerminated:
+		return websocket.ClosePolicyViolation
+	case exitReasonDisconnected, exitReasonServerShutdown:
+		return websocket.CloseGoingAway
+	default:
+		return websocket.CloseInternalServerErr
+	}
+}
+
+func (e execExit) httpCode() int32 {
+	switch e.Reason {
+	case exitReasonCompleted, exitReasonNonZeroExitCode:
+		return http.StatusOK
+	case exitReasonForbidden:
+		return http.StatusForbidden
+	case exitReasonPodNotFound:
+		return http.StatusNotFound
+	case exitReasonTimeout, exitReasonIdleTimeout, exitReasonMaxDuration:
+		return http.StatusRequestTimeout
+	case exitReasonContainerNotRunning:
+		return http.StatusConflict
+	case exitReasonShellNotFound:
+		return http.StatusUnprocessableEntity
+	case exitReasonTerminated:
+		return http.StatusGone
+	case exitReasonServerShutdown:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// channel 프로토콜 error 채널용 (k8s v4 이후 exec 상태 형식과 동일)
+func (e execExit) status() metav1.Status {
+	if e.Reason == exitReasonCompleted {
+		return metav1.Status{Status: metav1.StatusSuccess}
+	}
+	status := metav1.Status{
+		Status:  metav1.StatusFailure,
+		Reason:  metav1.StatusReason(e.Reason),
+		Message: e.Message,
+		Code:    e.httpCode(),
+	}
+	if e.ExitCode != nil {
+		status.Details = &metav1.StatusDetails{Causes: []metav1.StatusCause{{
+			Type:    remotecommandconsts.ExitCodeCauseType,
+			Message: fmt.Sprintf("%d", *e.ExitCode),
+		}}}
+	}
+	return status
+}
+
+// close frame reason 은 최대 123 바이트의 UTF-8. 멀티바이트 문자 중간에서 자르지 않음
+func (e execExit) closeText() string {
+	text := e.Message
+	if text == "" {
+		text = e.Reason
+	}
+	if len(text) > 123 {
+		end := 123
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+	}
+	return text
+}
+
+var containerNotRunningMessages = []string{
+	"container not found",
+	"is not running",
+	"is not valid for pod",
+	"is waiting to start",
+	"cannot exec into a container in a completed pod",
+}
+
+// executor.Stream 결과를 종료 코드와 사유로 변환. 필요 시 파드 상태를 조회해 컨테이너 종료 사유(OOMKilled 등)를 보강
+func describeExecExit(clientset kubernetes.Interface, namespace, pod, container string, startedAt time.Time, err error) execExit {
+	result := execExit{Type: controlTypeExit}
+	if err == nil {
+		code := 0
+		result.ExitCode = &code
+		result.Reason = exitReasonCompleted
+		result.Message = "process exited with 0"
+		return result
+	}
+
+	var exitErr exec.ExitError
+	switch {
+	case errors.Is(err, errShellNotFound):
+		result.Reason = exitReasonShellNotFound
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		code := exitErr.ExitStatus()
+		result.ExitCode = &code
+		result.Reason = exitReasonNonZeroExitCode
+	case apierrors.IsNotFound(err):
+		result.Reason = exitReasonPodNotFound
+	case apierrors.IsForbidden(err):
+		result.Reason = exitReasonForbidden
+	case apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) || errors.Is(err, context.DeadlineExceeded):
+		result.Reason = exitReasonTimeout
+	case isContainerNotRunningError(err):
+		result.Reason = exitReasonContainerNotRunning
+	default:
+		result.Reason = exitReasonError
+	}
+
+	if clientset != nil && (result.Reason == exitReasonNonZeroExitCode || result.Reason == exitReasonError) {
+		inspectContainerState(clientset, namespace, pod, container, startedAt, &result)
+	}
+
+	if result.ExitCode != nil {
+		result.Message = fmt.Sprintf("process exited with %d", *result.ExitCode)
+		if detail := exitDetail(result); detail != "" {
+			result.Message += " (" + detail + ")"
+		}
+	} else {
+		result.Message = err.Error()
+	}
+	return result
+}
+
+func isContainerNotRunningError(err error) bool {
+	msg := err.Error()
+	for _, m := range containerNotRunningMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// 스트림이 끊긴 원인이 파드 삭제나 컨테이너 종료인지 확인
+func inspectContainerState(clientset kubernetes.Interface, namespace, pod, container string, startedAt time.Time, result *execExit) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) && result.Reason == exitReasonError {
+			result.Reason = exitReasonPodNotFound
+		}
+		return
+	}
+	if podInfo.DeletionTimestamp != nil && result.Reason == exitReasonError {
+		result.Reason = exitReasonPodNotFound
+		return
+	}
+
+	for _, status := range podInfo.Status.ContainerStatuses {
+		if container != "" && status.Name != container {
+			continue
+		}
+		terminated := recentTermination(status, startedAt)
+		if terminated != nil {
+			result.ContainerReason = terminated.Reason
+			if result.Reason == exitReasonError {
+				result.Reason = exitReasonContainerNotRunning
+			}
+		} else if status.State.Running == nil && result.Reason == exitReasonError {
+			result.Reason = exitReasonContainerNotRunning
+		}
+		return
+	}
+}
+
+func recentTermination(status corev1.ContainerStatus, startedAt time.Time) *corev1.ContainerStateTerminated {
+	for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+		if terminated != nil && !terminated.FinishedAt.Time.Before(startedAt) {
+			return terminated
+		}
+	}
+	return nil
+}
+
+// 128+N 종료 코드의 N (리눅스 시그널 번호)
+var linuxSignalNames = map[int]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	3:  "SIGQUIT",
+	6:  "SIGABRT",
+	9:  "SIGKILL",
+	11: "SIGSEGV",
+	13: "SIGPIPE",
+	15: "SIGTERM",
+}
+
+func exitDetail(result execExit) string {
+	if result.ContainerReason != "" {
+		return result.ContainerReason
+	}
+	if result.ExitCode != nil && *result.ExitCode > 128 {
+		if name, ok := linuxSignalNames[*result.ExitCode-128]; ok {
+			return name
+		}
+	}
+	return ""
+}
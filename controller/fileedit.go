@@ -0,0 +1,366 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmezard/go-difflib/difflib"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	defaultFileEditMaxBytes = 1 << 20
+	// 감사 로그에 남기는 diff 최대 크기
+	fileEditMaxDiffBytes = 64 * 1024
+
+	auditEventFileEdit = "files.edit"
+
+	textEncodingUTF8    = "utf-8"
+	textEncodingUTF8BOM = "utf-8-bom"
+	textEncodingUTF16LE = "utf-16le"
+	textEncodingUTF16BE = "utf-16be"
+	textEncodingLatin1  = "iso-8859-1"
+
+	// 쓰기 스크립트가 동시 수정을 감지했을 때의 종료 코드
+	fileWriteConflictExitCode = 3
+)
+
+// 저장 스크립트. $1 대상, $2 임시 파일, $3 읽을 때의 체크섬(새 파일이면 빈 값), $4 uid:gid, $5 권한
+// 같은 디렉터리의 임시 파일에 쓴 뒤 rename 하므로 중간 상태가 보이지 않음
+// rename 직전에 체크섬을 다시 확인 (sha256sum 이 없는 이미지는 API 쪽 확인만 적용)
+const fileWriteScript = `set -C
+cat > "$2" || { rm -f "$2"; exit 1; }
+if [ -n "$4" ]; then chown "$4" "$2" 2>/dev/null; fi
+chmod "$5" "$2" || { rm -f "$2"; exit 1; }
+if [ -n "$3" ]; then
+	if command -v sha256sum >/dev/null 2>&1; then
+		cur=$(sha256sum < "$1") || { rm -f "$2"; exit 1; }
+		if [ "${cur%% *}" != "$3" ]; then rm -f "$2"; echo "$1: file changed since it was read" >&2; exit 3; fi
+	fi
+elif [ -e "$1" ] || [ -L "$1" ]; then
+	rm -f "$2"; echo "$1: file was created since it was read" >&2; exit 3
+fi
+mv -f "$2" "$1" || { rm -f "$2"; exit 1; }`
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+
+	errBinaryFile = errors.New("file is not a text file")
+)
+
+type WriteFileContentRequest struct {
+	Content  *string `json:"content"`
+	Checksum string  `json:"checksum"`
+	Encoding string  `json:"encoding,omitempty"`
+}
+
+func fileEditMaxBytes() int64 {
+	if config.Env != nil && config.Env.FileEditMaxBytes > 0 {
+		return config.Env.FileEditMaxBytes
+	}
+	return defaultFileEditMaxBytes
+}
+
+func fileChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// 문자 인코딩 판별. BOM 이 없고 UTF-8 이 아니면 제어 문자가 없는 경우에만 Latin-1 로 간주
+func decodeText(data []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		if !utf8.Valid(data[len(utf8BOM):]) {
+			return "", "", errBinaryFile
+		}
+		return string(data[len(utf8BOM):]), textEncodingUTF8BOM, nil
+	case bytes.HasPrefix(data, utf16LEBOM):
+		text, err := decodeUTF16(data[len(utf16LEBOM):], binary.LittleEndian)
+		return text, textEncodingUTF16LE, err
+	case bytes.HasPrefix(data, utf16BEBOM):
+		text, err := decodeUTF16(data[len(utf16BEBOM):], binary.BigEndian)
+		return text, textEncodingUTF16BE, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", "", errBinaryFile
+	}
+	if utf8.Valid(data) {
+		return string(data), textEncodingUTF8, nil
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\v' && b != 0x1b {
+			return "", "", errBinaryFile
+		}
+		runes[i] = rune(b)
+	}
+	return string(runes), textEncodingLatin1, nil
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) (string, error) {
+	if len(data)%2 != 0 {
+		return "", errBinaryFile
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// 읽을 때와 같은 인코딩(BOM 포함)으로 저장
+func encodeText(text, encoding string) ([]byte, error) {
+	switch encoding {
+	case textEncodingUTF8:
+		return []byte(text), nil
+	case textEncodingUTF8BOM:
+		return append(append([]byte{}, utf8BOM...), text...), nil
+	case textEncodingUTF16LE, textEncodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		data := append([]byte{}, utf16LEBOM...)
+		if encoding == textEncodingUTF16BE {
+			order, data = binary.BigEndian, append([]byte{}, utf16BEBOM...)
+		}
+		for _, unit := range utf16.Encode([]rune(text)) {
+			data = order.AppendUint16(data, unit)
+		}
+		return data, nil
+	case textEncodingLatin1:
+		data := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xFF {
+				return nil, fmt.Errorf("content cannot be encoded as %s: %q", textEncodingLatin1, r)
+			}
+			data = append(data, byte(r))
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+// 줄바꿈을 유지한 줄 단위 분리 (difflib.SplitLines 는 마지막에 빈 줄을 추가함)
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// 감사 로그용 unified diff. 제한을 넘으면 잘라냄
+func textDiff(name, before, after string) (string, bool) {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: "a" + name,
+		ToFile:   "b" + name,
+		Context:  3,
+	})
+	if len(diff) > fileEditMaxDiffBytes {
+		return diff[:fileEditMaxDiffBytes], true
+	}
+	return diff, false
+}
+
+// tar 로 일반 파일 하나를 읽음. 실패 시 HTTP 상태와 메시지 반환
+func (t *fileTarget) readFile(ctx context.Context, p string, maxBytes int64) (*tar.Header, []byte, int, string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startedAt := time.Now()
+	pr, pw := io.Pipe()
+	done := make(chan fileExecResult, 1)
+	go func() {
+		stderr, err := t.exec(ctx, tarCreateCommand(p), nil, pw)
+		pw.CloseWithError(err)
+		done <- fileExecResult{stderr, err}
+	}()
+	finish := func() fileExecResult {
+		io.Copy(io.Discard, pr)
+		return <-done
+	}
+
+	tr := tar.NewReader(pr)
+	hdr, err := tr.Next()
+	if err != nil {
+		result := finish()
+		if result.err == nil {
+			result.err = err
+		}
+		code, message := t.execError("tar", result.stderr, startedAt, result.err)
+		return nil, nil, code, message
+	}
+	switch {
+	case hdr.Typeflag == tar.TypeSymlink:
+		cancel()
+		finish()
+		return nil, nil, http.StatusConflict, fmt.Sprintf("%s is a symbolic link to %s", p, hdr.Linkname)
+	case hdr.Typeflag != tar.TypeReg:
+		cancel()
+		finish()
+		return nil, nil, http.StatusBadRequest, fmt.Sprintf("%s is not a regular file", p)
+	case hdr.Size > maxBytes:
+		cancel()
+		finish()
+		return nil, nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("%s is %d bytes; the edit limit is %d bytes", p, hdr.Size, maxBytes)
+	}
+	data, err := io.ReadAll(tr)
+	result := finish()
+	if err != nil {
+		if result.err == nil {
+			result.err = err
+		}
+		code, message := t.execError("tar", result.stderr, startedAt, result.err)
+		return nil, nil, code, message
+	}
+	return hdr, data, 0, ""
+}
+
+// GET /files/content?path=. 텍스트 파일 내용과 저장 시 보낼 체크섬 반환
+func ReadFileContentHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	hdr, data, code, message := target.readFile(c.Request.Context(), p, fileEditMaxBytes())
+	if code != 0 {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	text, encoding, err := decodeText(data)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("%s: %s", p, err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"path":     p,
+		"content":  text,
+		"encoding": encoding,
+		"checksum": fileChecksum(data),
+		"size":     len(data),
+		"mode":     formatFileMode(hdr.Mode),
+		"mtime":    hdr.ModTime.UTC(),
+	})
+}
+
+// PUT /files/content?path=. 읽을 때 받은 체크섬이 현재 내용과 다르면 409 (새 파일은 빈 체크섬)
+func WriteFileContentHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var request WriteFileContentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if request.Content == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "content is required"})
+		return
+	}
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	maxBytes := fileEditMaxBytes()
+	ctx := c.Request.Context()
+	hdr, current, code, message := target.readFile(ctx, p, maxBytes)
+	exists := code != http.StatusNotFound
+	if code != 0 && exists {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+
+	currentChecksum, before, encoding := "", "", textEncodingUTF8
+	mode, owner := int64(0644), ""
+	if exists {
+		currentChecksum = fileChecksum(current)
+		if before, encoding, err = decodeText(current); err != nil {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("%s: %s", p, err.Error())})
+			return
+		}
+		mode, owner = hdr.Mode&07777, fmt.Sprintf("%d:%d", hdr.Uid, hdr.Gid)
+	}
+	if request.Checksum != currentChecksum {
+		message := "file has been modified since it was read"
+		if !exists {
+			message = "file no longer exists"
+		} else if request.Checksum == "" {
+			message = "file already exists; checksum from the last read is required"
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": message, "checksum": currentChecksum})
+		return
+	}
+	if request.Encoding != "" {
+		encoding = request.Encoding
+	}
+	data, err := encodeText(*request.Content, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("content is %d bytes; the edit limit is %d bytes", len(data), maxBytes)})
+		return
+	}
+
+	tmp := path.Join(path.Dir(p), "."+path.Base(p)+".edit-"+utilrand.String(8))
+	command := []string{"sh", "-c", fileWriteScript, "sh", p, tmp, currentChecksum, owner, strconv.FormatInt(mode, 8)}
+	startedAt := time.Now()
+	stderr, err := target.exec(ctx, command, bytes.NewReader(data), io.Discard)
+
+	newChecksum := fileChecksum(data)
+	diff, truncated := textDiff(p, before, *request.Content)
+	details := map[string]interface{}{
+		"path":        p,
+		"bytes":       len(data),
+		"created":     !exists,
+		"checksum":    currentChecksum,
+		"newChecksum": newChecksum,
+		"diff":        diff,
+	}
+	if truncated {
+		details["diffTruncated"] = true
+	}
+	if err != nil {
+		var exitErr exec.ExitError
+		code, message := http.StatusConflict, stderr
+		if !errors.As(err, &exitErr) || !exitErr.Exited() || exitErr.ExitStatus() != fileWriteConflictExitCode {
+			code, message = target.execError("sh", stderr, startedAt, err)
+		}
+		details["error"] = message
+		audit.Log(target.auditEvent(auditEventFileEdit, details))
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	audit.Log(target.auditEvent(auditEventFileEdit, details))
+	c.JSON(http.StatusOK, gin.H{"path": p, "checksum": newChecksum, "size": len(data), "encoding": encoding})
+}
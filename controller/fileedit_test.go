@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// --- [ 텍스트 파일 편집 테스트 ] ---
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		text     string
+		encoding string
+	}{
+		{"UTF-8", []byte("name: 한글\n"), "name: 한글\n", textEncodingUTF8},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "a=1\r\n"...), "a=1\r\n", textEncodingUTF8BOM},
+		{"UTF-16LE", []byte{0xFF, 0xFE, 'o', 0, 'k', 0}, "ok", textEncodingUTF16LE},
+		{"UTF-16BE", []byte{0xFE, 0xFF, 0, 'o', 0, 'k'}, "ok", textEncodingUTF16BE},
+		{"Latin-1", []byte("caf\xe9\n"), "café\n", textEncodingLatin1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, encoding, err := decodeText(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.text, text)
+			assert.Equal(t, tt.encoding, encoding)
+
+			// 같은 인코딩으로 되돌리면 원본과 동일
+			data, err := encodeText(text, encoding)
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, data)
+		})
+	}
+
+	for _, binary := range [][]byte{{0x7f, 'E', 'L', 'F', 0, 1}, []byte("\xff\xd8\x01\x02"), {0xFF, 0xFE, 'x'}} {
+		_, _, err := decodeText(binary)
+		assert.ErrorIs(t, err, errBinaryFile)
+	}
+	_, err := encodeText("한글", textEncodingLatin1)
+	assert.Error(t, err)
+	_, err = encodeText("x", "ebcdic")
+	assert.Error(t, err)
+}
+
+func TestFileContentHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{FileEditMaxBytes: 64}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) { events = append(events, event) })
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+	// 컨테이너 파일 시스템 흉내. tar 는 읽기, sh 는 저장 스크립트
+	var files map[string][]byte
+	var writeCommand []string
+	var writeResult func(stdin []byte, options remotecommand.StreamOptions) error
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		command := opts.Command
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			if command[0] == "tar" {
+				name := command[4] + "/" + strings.TrimPrefix(command[5], "./")
+				data, ok := files[name]
+				if !ok {
+					io.WriteString(options.Stderr, "tar: "+command[5]+": Cannot stat: No such file or directory")
+					return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 2}
+				}
+				tw := tar.NewWriter(options.Stdout)
+				tw.WriteHeader(&tar.Header{Name: command[5], Mode: 0640, Uid: 101, Gid: 101, Size: int64(len(data)), ModTime: time.Unix(1792184775, 0), Typeflag: tar.TypeReg})
+				tw.Write(data)
+				return tw.Close()
+			}
+			writeCommand = command
+			stdin, _ := io.ReadAll(options.Stdin)
+			return writeResult(stdin, options)
+		}}, nil
+	})
+	saved := func(stdin []byte, options remotecommand.StreamOptions) error {
+		files[writeCommand[4]] = stdin
+		return nil
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "file-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/files/content", ReadFileContentHandler)
+	r.PUT("/files/content", WriteFileContentHandler)
+	const query = "&pod=p1&namespace=ns1&container=app&clusterId=c1"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		events = nil
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path+query, strings.NewReader(body))
+		r.ServeHTTP(w, req)
+		return w
+	}
+	read := func(t *testing.T, p string) map[string]interface{} {
+		w := serve(http.MethodGet, "/files/content?path="+p, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	write := func(p string, request WriteFileContentRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		return serve(http.MethodPut, "/files/content?path="+p, string(body))
+	}
+	content := func(s string) *string { return &s }
+
+	t.Run("Read", func(t *testing.T) {
+		files = map[string][]byte{"/app/app.conf": []byte("port=8080\n")}
+		body := read(t, "/app/app.conf")
+		assert.Equal(t, "port=8080\n", body["content"])
+		assert.Equal(t, textEncodingUTF8, body["encoding"])
+		assert.Equal(t, fileChecksum([]byte("port=8080\n")), body["checksum"])
+		assert.Equal(t, "0640", body["mode"])
+	})
+
+	t.Run("Read - Rejected", func(t *testing.T) {
+		files = map[string][]byte{"/bin/app": {0x7f, 'E', 'L', 'F', 0}, "/tmp/big.log": bytes.Repeat([]byte("x"), 100)}
+		assert.Equal(t, http.StatusUnsupportedMediaType, serve(http.MethodGet, "/files/content?path=/bin/app", "").Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(http.MethodGet, "/files/content?path=/tmp/big.log", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/files/content?path=/nope", "").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/files/content?path=app.conf", "").Code)
+	})
+
+	t.Run("Write - Success", func(t *testing.T) {
+		files = map[string][]byte{"/app/app.conf": []byte("name=web\nport=8080\n")}
+		writeResult = saved
+		checksum := read(t, "/app/app.conf")["checksum"].(string)
+
+		w := write("/app/app.conf", WriteFileContentRequest{Content: content("name=web\nport=9090\n"), Checksum: checksum})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "name=web\nport=9090\n", string(files["/app/app.conf"]))
+		assert.JSONEq(t, `{"path":"/app/app.conf","checksum":"`+fileChecksum(files["/app/app.conf"])+`","size":19,"encoding":"utf-8"}`, w.Body.String())
+
+		// 같은 디렉터리의 임시 파일, 기존 소유자와 권한 유지
+		assert.Equal(t, fileWriteScript, writeCommand[2])
+		assert.Regexp(t, `^/app/\.app\.conf\.edit-\w{8}$`, writeCommand[5])
+		assert.Equal(t, []string{checksum, "101:101", "640"}, writeCommand[6:])
+
+		require.Len(t, events, 1)
+		assert.Equal(t, auditEventFileEdit, events[0].Type)
+		assert.Equal(t, "file-user", events[0].User)
+		assert.Equal(t, "--- a/app/app.conf\n+++ b/app/app.conf\n@@ -1,2 +1,2 @@\n name=web\n-port=8080\n+port=9090\n", events[0].Details["diff"])
+	})
+
+	t.Run("Write - Preserves encoding", func(t *testing.T) {
+		files = map[string][]byte{"/app/win.ini": {0xFF, 0xFE, 'a', 0}}
+		writeResult = saved
+		w := write("/app/win.ini", WriteFileContentRequest{Content: content("b"), Checksum: fileChecksum(files["/app/win.ini"])})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []byte{0xFF, 0xFE, 'b', 0}, files["/app/win.ini"])
+	})
+
+	t.Run("Write - New file", func(t *testing.T) {
+		files = map[string][]byte{}
+		writeResult = saved
+		w := write("/app/new.conf", WriteFileContentRequest{Content: content("x=1\n")})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"", "", "644"}, writeCommand[6:])
+		assert.Equal(t, true, events[0].Details["created"])
+	})
+
+	t.Run("Write - Stale checksum", func(t *testing.T) {
+		files = map[string][]byte{"/app/app.conf": []byte("port=7070\n")}
+		writeCommand = nil
+		w := write("/app/app.conf", WriteFileContentRequest{Content: content("port=9090\n"), Checksum: fileChecksum([]byte("port=8080\n"))})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), fileChecksum([]byte("port=7070\n")))
+		assert.Nil(t, writeCommand)
+
+		// 새 파일로 저장하려 했지만 이미 존재
+		assert.Equal(t, http.StatusConflict, write("/app/app.conf", WriteFileContentRequest{Content: content("x")}).Code)
+		// 읽은 뒤 삭제됨
+		assert.Equal(t, http.StatusConflict, write("/app/gone.conf", WriteFileContentRequest{Content: content("x"), Checksum: "abc"}).Code)
+	})
+
+	t.Run("Write - Changed during save", func(t *testing.T) {
+		files = map[string][]byte{"/app/app.conf": []byte("port=8080\n")}
+		writeResult = func(stdin []byte, options remotecommand.StreamOptions) error {
+			io.WriteString(options.Stderr, "/app/app.conf: file changed since it was read")
+			return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: fileWriteConflictExitCode}
+		}
+		w := write("/app/app.conf", WriteFileContentRequest{Content: content("port=9090\n"), Checksum: fileChecksum(files["/app/app.conf"])})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		require.Len(t, events, 1)
+		assert.Equal(t, "/app/app.conf: file changed since it was read", events[0].Details["error"])
+	})
+
+	t.Run("Write - Read-only file system", func(t *testing.T) {
+		files = map[string][]byte{"/etc/hosts": []byte("127.0.0.1 localhost\n")}
+		writeResult = func(stdin []byte, options remotecommand.StreamOptions) error {
+			io.WriteString(options.Stderr, "sh: can't create /etc/.hosts.edit-abc: Read-only file system")
+			return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 1}
+		}
+		w := write("/etc/hosts", WriteFileContentRequest{Content: content("x"), Checksum: fileChecksum(files["/etc/hosts"])})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "Read-only file system")
+	})
+
+	t.Run("Write - Invalid request", func(t *testing.T) {
+		files = map[string][]byte{"/app/app.conf": []byte("a")}
+		checksum := fileChecksum(files["/app/app.conf"])
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/files/content?path=/app/app.conf", `{"checksum":"x"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/files/content?path=/app/app.conf", `not json`).Code)
+		assert.Equal(t, http.StatusBadRequest, write("/app/app.conf", WriteFileContentRequest{Content: content("a"), Checksum: checksum, Encoding: "ebcdic"}).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, write("/app/app.conf", WriteFileContentRequest{Content: content(strings.Repeat("x", 100)), Checksum: checksum}).Code)
+	})
+}
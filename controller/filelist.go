@@ -0,0 +1,409 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultFileListLimit = 500
+	maxFileListLimit     = 5000
+	fileListTimeout      = 30 * time.Second
+	// 이름, 링크 대상 등 항목 필드 하나의 최대 크기
+	maxFileListFieldBytes = 8 * 1024
+)
+
+// 목록 스크립트. $1 경로, $2 list|stat
+// GNU find -printf 를 우선 사용하고, 없으면 stat -c, 그것도 없으면 ls -ln 출력으로 대체 (busybox 등)
+// 첫 토큰으로 출력 형식을 알려주며 find/stat 형식은 NUL 구분이라 이름에 개행이 있어도 안전
+// list 는 경로 끝에 / 를 붙여 디렉터리를 가리키는 심볼릭 링크도 따라감
+const fileListScript = `p=$1
+if [ ! -e "$p" ] && [ ! -L "$p" ]; then echo "$p: No such file or directory" >&2; exit 2; fi
+if [ "$2" = list ] && [ ! -d "$p" ]; then echo "$p: Not a directory" >&2; exit 2; fi
+if find / -maxdepth 0 -printf '' >/dev/null 2>&1; then
+	printf 'find\0'
+	if [ "$2" = list ]; then exec find "$p/" -mindepth 1 -maxdepth 1 -printf '%f\0%y\0%s\0%m\0%u\0%g\0%T@\0%l\0'; fi
+	exec find "$p" -maxdepth 0 -printf '%f\0%y\0%s\0%m\0%u\0%g\0%T@\0%l\0'
+fi
+if stat -c %s / >/dev/null 2>&1; then
+	printf 'stat\0'
+	if [ "$2" = list ]; then cd "$p/" || exit 2; set -- * .[!.]* ..?*; else set -- "$p"; fi
+	for f; do
+		[ -e "$f" ] || [ -L "$f" ] || continue
+		s=$(stat -c '%f %s %U %G %Y' -- "$f") || continue
+		l=
+		if [ -L "$f" ]; then l=$(readlink -- "$f"); fi
+		printf '%s\0%s\0%s\0' "$f" "$s" "$l"
+	done
+	exit 0
+fi
+printf 'ls\0'
+if [ "$2" = list ]; then exec ls -lnA -- "$p/"; fi
+exec ls -lnd -- "$p"`
+
+const (
+	fileListScopeList = "list"
+	fileListScopeStat = "stat"
+)
+
+// 파일 목록 항목
+type fileEntry struct {
+	Path    string    `json:"path,omitempty"`
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	Owner   string    `json:"owner"`
+	Group   string    `json:"group"`
+	ModTime time.Time `json:"mtime"`
+	Target  string    `json:"target,omitempty"`
+}
+
+// find %y 형식 문자
+var findFileTypes = map[string]string{
+	"f": "file", "d": "directory", "l": "symlink", "p": "fifo", "s": "socket", "c": "char", "b": "block",
+}
+
+// ls -l 첫 글자
+var lsFileTypes = map[byte]string{
+	'-': "file", 'd': "directory", 'l': "symlink", 'p': "fifo", 's': "socket", 'c': "char", 'b': "block",
+}
+
+// st_mode 의 S_IFMT 비트
+func rawModeType(mode int64) string {
+	switch mode & 0170000 {
+	case 0100000:
+		return "file"
+	case 0040000:
+		return "directory"
+	case 0120000:
+		return "symlink"
+	case 0010000:
+		return "fifo"
+	case 0140000:
+		return "socket"
+	case 0020000:
+		return "char"
+	case 0060000:
+		return "block"
+	}
+	return "unknown"
+}
+
+func formatFileMode(mode int64) string {
+	return fmt.Sprintf("%04o", mode&07777)
+}
+
+// find %T@ (소수점 이하 초 포함)
+func parseEpoch(value string) (time.Time, error) {
+	sec, frac, _ := strings.Cut(value, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec int64
+	if frac != "" {
+		frac = (frac + "000000000")[:9]
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(s, nsec).UTC(), nil
+}
+
+// NUL 로 끝나는 필드 n 개. 첫 필드 전에 출력이 끝나면 io.EOF
+func readNulFields(r *bufio.Reader, n int) ([]string, error) {
+	fields := make([]string, n)
+	for i := range fields {
+		field, err := r.ReadSlice(0)
+		if err != nil {
+			if err == io.EOF && i == 0 && len(field) == 0 {
+				return nil, io.EOF
+			}
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		fields[i] = string(field[:len(field)-1])
+	}
+	return fields, nil
+}
+
+// name, type, size, mode(8진), owner, group, mtime, link
+func findEntry(fields []string) (fileEntry, error) {
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid size %q", fields[2])
+	}
+	mode, err := strconv.ParseInt(fields[3], 8, 64)
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid mode %q", fields[3])
+	}
+	mtime, err := parseEpoch(fields[6])
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid mtime %q", fields[6])
+	}
+	fileType, ok := findFileTypes[fields[1]]
+	if !ok {
+		fileType = "unknown"
+	}
+	return fileEntry{Name: fields[0], Type: fileType, Size: size, Mode: formatFileMode(mode), Owner: fields[4], Group: fields[5], ModTime: mtime, Target: fields[7]}, nil
+}
+
+// name, "rawmode(16진) size owner group mtime", link
+func statEntry(fields []string) (fileEntry, error) {
+	info := strings.Fields(fields[1])
+	if len(info) != 5 {
+		return fileEntry{}, fmt.Errorf("invalid stat output %q", fields[1])
+	}
+	mode, err := strconv.ParseInt(info[0], 16, 64)
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid mode %q", info[0])
+	}
+	size, err := strconv.ParseInt(info[1], 10, 64)
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid size %q", info[1])
+	}
+	sec, err := strconv.ParseInt(info[4], 10, 64)
+	if err != nil {
+		return fileEntry{}, fmt.Errorf("invalid mtime %q", info[4])
+	}
+	return fileEntry{Name: path.Base(fields[0]), Type: rawModeType(mode), Size: size, Mode: formatFileMode(mode), Owner: info[2], Group: info[3], ModTime: time.Unix(sec, 0).UTC(), Target: fields[2]}, nil
+}
+
+// 예: drwxr-xr-x    2 0        0             4096 Jan  2 15:04 name -> target (장치 파일은 크기 자리에 major, minor)
+var lsLinePattern = regexp.MustCompile(`^([-bcdlps])([-rwxsStT]{9})\S*\s+\d+\s+(\S+)\s+(\S+)\s+(?:\d+,\s*)?(\d+)\s+(\S+\s+\d+\s+[\d:]+) (.*)$`)
+
+func lsMode(perm string) int64 {
+	var mode int64
+	for i := 0; i < 9; i++ {
+		switch perm[i] {
+		case 'r', 'w', 'x', 's', 't':
+			mode |= 1 << (8 - i)
+		}
+	}
+	if perm[2] == 's' || perm[2] == 'S' {
+		mode |= 04000
+	}
+	if perm[5] == 's' || perm[5] == 'S' {
+		mode |= 02000
+	}
+	if perm[8] == 't' || perm[8] == 'T' {
+		mode |= 01000
+	}
+	return mode
+}
+
+// 최근 파일은 연도 없이 시각만 표시됨 (컨테이너 시간대는 알 수 없어 UTC 로 간주)
+func lsModTime(value string, now time.Time) time.Time {
+	value = strings.Join(strings.Fields(value), " ")
+	if strings.Contains(value, ":") {
+		t, err := time.Parse("Jan 2 15:04", value)
+		if err != nil {
+			return time.Time{}
+		}
+		t = t.AddDate(now.Year(), 0, 0)
+		if t.After(now.AddDate(0, 0, 1)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t
+	}
+	t, _ := time.Parse("Jan 2 2006", value)
+	return t
+}
+
+func lsEntry(line string, now time.Time) (fileEntry, bool) {
+	m := lsLinePattern.FindStringSubmatch(line)
+	if m == nil {
+		return fileEntry{}, false
+	}
+	size, _ := strconv.ParseInt(m[5], 10, 64)
+	entry := fileEntry{Name: m[7], Type: lsFileTypes[m[1][0]], Size: size, Mode: formatFileMode(lsMode(m[2])), Owner: m[3], Group: m[4], ModTime: lsModTime(m[6], now)}
+	if entry.Type == "symlink" {
+		entry.Name, entry.Target, _ = strings.Cut(entry.Name, " -> ")
+	}
+	entry.Name = path.Base(entry.Name)
+	return entry, true
+}
+
+// 목록 스크립트 출력 파싱
+func parseFileEntries(r io.Reader, fn func(fileEntry)) error {
+	br := bufio.NewReaderSize(r, maxFileListFieldBytes)
+	header, err := readNulFields(br, 1)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch header[0] {
+	case "find", "stat":
+		n, parse := 8, findEntry
+		if header[0] == "stat" {
+			n, parse = 3, statEntry
+		}
+		for {
+			fields, err := readNulFields(br, n)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			entry, err := parse(fields)
+			if err != nil {
+				return err
+			}
+			fn(entry)
+		}
+	case "ls":
+		now := time.Now().UTC()
+		for {
+			line, err := br.ReadSlice('\n')
+			if len(line) > 0 {
+				if entry, ok := lsEntry(strings.TrimRight(string(line), "\r\n"), now); ok {
+					fn(entry)
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("unexpected listing format %q", header[0])
+}
+
+// 컨테이너에서 목록 스크립트를 실행하고 항목마다 fn 호출. 실패 시 HTTP 상태와 메시지 반환
+func (t *fileTarget) listEntries(ctx context.Context, p, scope string, fn func(fileEntry)) (int, string) {
+	ctx, cancel := context.WithTimeout(ctx, fileListTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	pr, pw := io.Pipe()
+	done := make(chan fileExecResult, 1)
+	go func() {
+		stderr, err := t.exec(ctx, []string{"sh", "-c", fileListScript, "sh", p, scope}, nil, pw)
+		pw.CloseWithError(err)
+		done <- fileExecResult{stderr, err}
+	}()
+	parseErr := parseFileEntries(pr, fn)
+	if parseErr != nil {
+		pr.CloseWithError(parseErr)
+	} else {
+		io.Copy(io.Discard, pr)
+	}
+	result := <-done
+
+	// 실행 실패가 파싱 오류의 원인이면 실행 실패를 보고
+	if result.err != nil && (parseErr == nil || errors.Is(parseErr, result.err)) {
+		return t.execError("sh", result.stderr, startedAt, result.err)
+	}
+	if parseErr != nil {
+		return http.StatusInternalServerError, "failed to parse file listing: " + parseErr.Error()
+	}
+	return 0, ""
+}
+
+// GET /files/list?path=&limit=&continue=. 이름순 정렬, continue 는 이전 페이지의 마지막 이름
+func ListFilesHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := defaultFileListLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxFileListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxFileListLimit)})
+			return
+		}
+	}
+	after := c.Query("continue")
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	// 큰 디렉터리도 limit+1 개만 유지
+	entries := []fileEntry{}
+	trim := func() {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		if len(entries) > limit+1 {
+			entries = entries[:limit+1]
+		}
+	}
+	code, message := target.listEntries(c.Request.Context(), p, fileListScopeList, func(entry fileEntry) {
+		if entry.Name <= after {
+			return
+		}
+		entries = append(entries, entry)
+		if len(entries) >= 2*(limit+1) {
+			trim()
+		}
+	})
+	if code != 0 {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	trim()
+
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = entries[limit-1].Name
+	}
+	c.JSON(http.StatusOK, gin.H{"path": p, "entries": entries, "continue": next})
+}
+
+// GET /files/stat?path=. 심볼릭 링크는 따라가지 않음
+func StatFileHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	entry, code, message := target.stat(c.Request.Context(), p)
+	if code != 0 {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// 경로 하나의 정보. 실패 시 HTTP 상태와 메시지 반환
+func (t *fileTarget) stat(ctx context.Context, p string) (fileEntry, int, string) {
+	var entries []fileEntry
+	code, message := t.listEntries(ctx, p, fileListScopeStat, func(entry fileEntry) {
+		entries = append(entries, entry)
+	})
+	if code != 0 {
+		return fileEntry{}, code, message
+	}
+	if len(entries) != 1 {
+		return fileEntry{}, http.StatusInternalServerError, "unexpected stat output"
+	}
+	entry := entries[0]
+	entry.Path = p
+	return entry, 0, ""
+}
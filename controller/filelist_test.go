@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// --- [ 파일 목록 테스트 ] ---
+
+func nulJoin(fields ...string) string {
+	return strings.Join(fields, "\x00") + "\x00"
+}
+
+func TestParseFileEntries(t *testing.T) {
+	mtime := time.Unix(1792184775, 0).UTC()
+	parse := func(t *testing.T, output string) []fileEntry {
+		var entries []fileEntry
+		require.NoError(t, parseFileEntries(strings.NewReader(output), func(entry fileEntry) { entries = append(entries, entry) }))
+		return entries
+	}
+
+	t.Run("GNU find", func(t *testing.T) {
+		entries := parse(t, nulJoin("find",
+			"a b", "f", "3", "4755", "root", "root", "1792184775.6695094690", "",
+			"new\nline", "f", "1", "644", "1000", "1000", "1792184775", "",
+			"link", "l", "1", "777", "root", "root", "1792184775.5", "d",
+		))
+		require.Len(t, entries, 3)
+		assert.Equal(t, fileEntry{Name: "a b", Type: "file", Size: 3, Mode: "4755", Owner: "root", Group: "root", ModTime: mtime.Add(669509469)}, entries[0])
+		assert.Equal(t, "new\nline", entries[1].Name)
+		assert.Equal(t, "0644", entries[1].Mode)
+		assert.Equal(t, fileEntry{Name: "link", Type: "symlink", Size: 1, Mode: "0777", Owner: "root", Group: "root", ModTime: mtime.Add(500 * time.Millisecond), Target: "d"}, entries[2])
+	})
+
+	t.Run("stat fallback", func(t *testing.T) {
+		entries := parse(t, nulJoin("stat",
+			"d", "41ed 4096 root root 1792184775", "",
+			"fifo", "11a4 0 root root 1792184775", "",
+			"/tmp/fl/dangling", "a1ff 5 nobody nogroup 1792184775", "/nope",
+		))
+		require.Len(t, entries, 3)
+		assert.Equal(t, fileEntry{Name: "d", Type: "directory", Size: 4096, Mode: "0755", Owner: "root", Group: "root", ModTime: mtime}, entries[0])
+		assert.Equal(t, "fifo", entries[1].Type)
+		assert.Equal(t, fileEntry{Name: "dangling", Type: "symlink", Size: 5, Mode: "0777", Owner: "nobody", Group: "nogroup", ModTime: mtime, Target: "/nope"}, entries[2])
+	})
+
+	t.Run("ls fallback", func(t *testing.T) {
+		entries := parse(t, "ls\x00total 12\n"+
+			"-rwsr-xr-x    1 0        0                3 Oct 16 21:06 a b\n"+
+			"drwxrwxrwt    2 0        0             4096 Jan  2  2024 tmp\n"+
+			"crw-rw-rw-    1 0        0           1,   3 Jan  2  2024 null\n"+
+			"lrwxrwxrwx    1 0        0                5 Jan  2  2024 /tmp/fl/dangling -> /nope\n")
+		require.Len(t, entries, 4)
+		assert.Equal(t, "a b", entries[0].Name)
+		assert.Equal(t, "4755", entries[0].Mode)
+		assert.Equal(t, time.October, entries[0].ModTime.Month())
+		assert.Equal(t, fileEntry{Name: "tmp", Type: "directory", Size: 4096, Mode: "1777", Owner: "0", Group: "0", ModTime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, entries[1])
+		assert.Equal(t, "char", entries[2].Type)
+		assert.Equal(t, int64(3), entries[2].Size)
+		assert.Equal(t, "dangling", entries[3].Name)
+		assert.Equal(t, "/nope", entries[3].Target)
+	})
+
+	t.Run("Invalid output", func(t *testing.T) {
+		assert.Error(t, parseFileEntries(strings.NewReader(nulJoin("dir", "x")), func(fileEntry) {}))
+		assert.Error(t, parseFileEntries(strings.NewReader(nulJoin("find", "a", "f", "huge")), func(fileEntry) {}))
+		assert.Error(t, parseFileEntries(strings.NewReader("find\x00"+strings.Repeat("x", 2*maxFileListFieldBytes)), func(fileEntry) {}))
+		assert.NoError(t, parseFileEntries(strings.NewReader(""), func(fileEntry) {}))
+	})
+}
+
+func TestFileListHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+	var command []string
+	var stream func(options remotecommand.StreamOptions) error
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		command = opts.Command
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			return stream(options)
+		}}, nil
+	})
+	output := func(output string) func(options remotecommand.StreamOptions) error {
+		return func(options remotecommand.StreamOptions) error {
+			_, err := io.WriteString(options.Stdout, output)
+			return err
+		}
+	}
+	failure := func(stderr string, code int) func(options remotecommand.StreamOptions) error {
+		return func(options remotecommand.StreamOptions) error {
+			io.WriteString(options.Stderr, stderr)
+			return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: code}
+		}
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "file-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/files/list", ListFilesHandler)
+	r.GET("/files/stat", StatFileHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path+"&pod=p1&namespace=ns1&container=app&clusterId=c1", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var listing []string
+	for _, name := range []string{"e", "c", "a", "d", "b"} {
+		listing = append(listing, name, "f", "1", "644", "root", "root", "1792184775", "")
+	}
+	type listResponse struct {
+		Path     string      `json:"path"`
+		Entries  []fileEntry `json:"entries"`
+		Continue string      `json:"continue"`
+	}
+	names := func(entries []fileEntry) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+
+	t.Run("List - Paginated", func(t *testing.T) {
+		stream = output(nulJoin(append([]string{"find"}, listing...)...))
+		w := get("/files/list?path=/app/&limit=2")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"sh", "-c", fileListScript, "sh", "/app", fileListScopeList}, command)
+		var page listResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Equal(t, "/app", page.Path)
+		assert.Equal(t, []string{"a", "b"}, names(page.Entries))
+		assert.Equal(t, "b", page.Continue)
+
+		w = get("/files/list?path=/app&limit=2&continue=d")
+		require.Equal(t, http.StatusOK, w.Code)
+		page = listResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Equal(t, []string{"e"}, names(page.Entries))
+		assert.Empty(t, page.Continue)
+	})
+
+	t.Run("List - Empty directory", func(t *testing.T) {
+		stream = output(nulJoin("find"))
+		w := get("/files/list?path=/empty")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"path":"/empty","entries":[],"continue":""}`, w.Body.String())
+	})
+
+	t.Run("List - Errors", func(t *testing.T) {
+		stream = failure("/missing: No such file or directory", 2)
+		assert.Equal(t, http.StatusNotFound, get("/files/list?path=/missing").Code)
+
+		stream = failure("/etc/hosts: Not a directory", 2)
+		assert.Equal(t, http.StatusBadRequest, get("/files/list?path=/etc/hosts").Code)
+
+		stream = failure("find: '/root/': Permission denied", 1)
+		assert.Equal(t, http.StatusForbidden, get("/files/list?path=/root").Code)
+
+		stream = func(options remotecommand.StreamOptions) error {
+			return errors.New(`exec: "sh": executable file not found in $PATH`)
+		}
+		w := get("/files/list?path=/")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "sh is not available in the container")
+
+		stream = output(nulJoin("find", "a", "f", "not-a-size", "644", "root", "root", "0", ""))
+		assert.Equal(t, http.StatusInternalServerError, get("/files/list?path=/").Code)
+	})
+
+	t.Run("List - Invalid request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/files/list?path=app").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/files/list?path=/app&limit=0").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/files/list?path=/app&limit=5001").Code)
+	})
+
+	t.Run("Stat - Symlink", func(t *testing.T) {
+		stream = output(nulJoin("stat", "/app/current", "a1ff 11 app app 1792184775", "releases/v2"))
+		w := get("/files/stat?path=/app/current")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, fileListScopeStat, command[len(command)-1])
+		assert.JSONEq(t, `{"path":"/app/current","name":"current","type":"symlink","size":11,"mode":"0777","owner":"app","group":"app","mtime":"2026-10-16T21:06:15Z","target":"releases/v2"}`, w.Body.String())
+	})
+
+	t.Run("Stat - Not found", func(t *testing.T) {
+		stream = failure("/nope: No such file or directory", 2)
+		assert.Equal(t, http.StatusNotFound, get("/files/stat?path=/nope").Code)
+	})
+}
@@ -0,0 +1,597 @@
+package controller
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	defaultFileDownloadMaxBytes = 1 << 30
+	defaultFileUploadMaxBytes   = 100 << 20
+	// 오류 판단용으로 보관하는 stderr 크기
+	fileStderrLimit = 4096
+
+	auditEventFileDownload = "files.download"
+	auditEventFileUpload   = "files.upload"
+)
+
+var errFileTooLarge = errors.New("file exceeds the size limit")
+
+func fileDownloadMaxBytes() int64 {
+	if config.Env != nil && config.Env.FileDownloadMaxBytes > 0 {
+		return config.Env.FileDownloadMaxBytes
+	}
+	return defaultFileDownloadMaxBytes
+}
+
+func fileUploadMaxBytes() int64 {
+	if config.Env != nil && config.Env.FileUploadMaxBytes > 0 {
+		return config.Env.FileUploadMaxBytes
+	}
+	return defaultFileUploadMaxBytes
+}
+
+// 컨테이너 내부 경로 검증. 절대 경로만 허용하며 정규화된 경로 반환
+func containerPath(p string) (string, error) {
+	if p == "" {
+		return "", errors.New("path is required")
+	}
+	if !path.IsAbs(p) || strings.ContainsRune(p, 0) {
+		return "", fmt.Errorf("path must be absolute: %q", p)
+	}
+	return path.Clean(p), nil
+}
+
+// 파일 API 대상 컨테이너와 사용자별 자격 증명
+type fileTarget struct {
+	clientset kubernetes.Interface
+	cfg       *rest.Config
+	User      string
+	UserType  string
+	Cluster   string
+	Namespace string
+	Pod       string
+	Container string
+	ClientIP  string
+}
+
+// 실패 시 응답을 작성하고 false 반환
+func newFileTarget(c *gin.Context) (*fileTarget, bool) {
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return nil, false
+	}
+	claims := val.(jwt.MapClaims)
+	target := &fileTarget{
+		User:      claims["userAuthId"].(string),
+		UserType:  claims["userType"].(string),
+		Cluster:   c.Query("clusterId"),
+		Namespace: c.Query("namespace"),
+		Pod:       c.Query("pod"),
+		Container: c.Query("container"),
+		ClientIP:  c.ClientIP(),
+	}
+
+	return target, target.connect(c)
+}
+
+// 사용자별 자격 증명으로 clientset 생성. 실패 시 응답을 작성하고 false 반환
+func (t *fileTarget) connect(c *gin.Context) bool {
+	clusterInfo, err := GetClusterInfo(t.Cluster, t.User, t.UserType, t.Namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return false
+	}
+	t.cfg = &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	t.clientset, err = K8sClientFactoryImpl.NewForConfig(t.cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create clientset: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// 비대화형 명령 실행. stderr 는 오류 판단용으로 앞부분만 반환
+func (t *fileTarget) exec(ctx context.Context, command []string, stdin io.Reader, stdout io.Writer) (string, error) {
+	executor, err := newExecutor(t.clientset, t.cfg, t.Pod, t.Namespace, &corev1.PodExecOptions{
+		Container: t.Container,
+		Command:   command,
+		Stdin:     stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	})
+	if err != nil {
+		return "", err
+	}
+	stderr := &limitedBuffer{limit: fileStderrLimit}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: stderr})
+	return strings.TrimSpace(stderr.String()), err
+}
+
+func (t *fileTarget) auditEvent(eventType string, details map[string]interface{}) audit.Event {
+	return audit.Event{
+		Type:      eventType,
+		User:      t.User,
+		UserType:  t.UserType,
+		Cluster:   t.Cluster,
+		Namespace: t.Namespace,
+		Pod:       t.Pod,
+		Container: t.Container,
+		ClientIP:  t.ClientIP,
+		Details:   details,
+	}
+}
+
+// 컨테이너에 명령이 없는 경우 (런타임 오류 메시지 또는 셸의 126/127 종료 코드)
+func isCommandNotFound(err error) bool {
+	var exitErr exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		return exitErr.ExitStatus() == 126 || exitErr.ExitStatus() == 127
+	}
+	return strings.Contains(err.Error(), "executable file not found")
+}
+
+// 실행 실패를 HTTP 상태와 메시지로 변환
+func (t *fileTarget) execError(command, stderr string, startedAt time.Time, err error) (int, string) {
+	if isCommandNotFound(err) {
+		return http.StatusUnprocessableEntity, fmt.Sprintf("%s is not available in the container; file operations require %s in the image", command, command)
+	}
+	detail := stderr
+	if detail == "" {
+		detail = err.Error()
+	}
+	switch {
+	case strings.Contains(stderr, "No such file or directory"):
+		return http.StatusNotFound, detail
+	case strings.Contains(stderr, "Permission denied"):
+		return http.StatusForbidden, detail
+	case strings.Contains(stderr, "Read-only file system"):
+		return http.StatusConflict, detail
+	case strings.Contains(stderr, "Not a directory"):
+		return http.StatusBadRequest, detail
+	}
+	exit := describeExecExit(t.clientset, t.Namespace, t.Pod, t.Container, startedAt, err)
+	if exit.Reason == exitReasonNonZeroExitCode {
+		return http.StatusInternalServerError, fmt.Sprintf("%s failed: %s", command, detail)
+	}
+	return int(exit.httpCode()), exit.Message
+}
+
+// -C 로 상위 디렉터리에 들어가 ./이름 으로 지정 (이름이 - 로 시작해도 옵션으로 해석되지 않음)
+func tarCreateCommand(p string) []string {
+	if p == "/" {
+		return []string{"tar", "cf", "-", "-C", "/", "."}
+	}
+	return []string{"tar", "cf", "-", "-C", path.Dir(p), "./" + path.Base(p)}
+}
+
+// 소유자는 컨테이너 사용자로 (-o)
+func tarExtractCommand(dir string) []string {
+	return []string{"tar", "xof", "-", "-C", dir}
+}
+
+func attachmentHeader(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+type fileExecResult struct {
+	stderr string
+	err    error
+}
+
+// GET /files/download?path=. 파일은 내용 그대로, 디렉터리(또는 format=tar)는 tar 로 전송
+func DownloadFileHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.Query("format")
+	if format != "" && format != "tar" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be tar"})
+		return
+	}
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	startedAt := time.Now()
+	pr, pw := io.Pipe()
+	done := make(chan fileExecResult, 1)
+	go func() {
+		stderr, err := target.exec(ctx, tarCreateCommand(p), nil, pw)
+		pw.CloseWithError(err)
+		done <- fileExecResult{stderr, err}
+	}()
+	// 응답을 시작한 뒤에도 tar 가 끝날 때까지 대기
+	finish := func() fileExecResult {
+		io.Copy(io.Discard, pr)
+		return <-done
+	}
+
+	maxBytes := fileDownloadMaxBytes()
+	tr := tar.NewReader(bufio.NewReaderSize(pr, 64*1024))
+	hdr, err := tr.Next()
+	if err != nil {
+		// tar 가 아무 항목도 만들지 못한 경우 (명령 없음, 경로 없음 등)
+		result := finish()
+		if result.err == nil {
+			result.err = err
+		}
+		code, message := target.execError("tar", result.stderr, startedAt, result.err)
+		audit.Log(target.auditEvent(auditEventFileDownload, map[string]interface{}{"path": p, "error": message}))
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+
+	name := path.Base(p)
+	if name == "/" {
+		name = "root"
+	}
+	var written int64
+	switch {
+	case hdr.Typeflag == tar.TypeSymlink && format == "":
+		cancel()
+		finish()
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is a symbolic link to %s", p, hdr.Linkname)})
+		return
+	case hdr.Typeflag == tar.TypeReg && format == "":
+		if hdr.Size > maxBytes {
+			cancel()
+			finish()
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s is %d bytes; the download limit is %d bytes", p, hdr.Size, maxBytes)})
+			return
+		}
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Disposition", attachmentHeader(name))
+		c.Header("Content-Length", strconv.FormatInt(hdr.Size, 10))
+		c.Status(http.StatusOK)
+		written, err = io.Copy(c.Writer, tr)
+	default:
+		c.Header("Content-Type", "application/x-tar")
+		c.Header("Content-Disposition", attachmentHeader(name+".tar"))
+		c.Status(http.StatusOK)
+		written, err = copyTarLimited(c.Writer, tr, hdr, maxBytes)
+	}
+	result := finish()
+
+	details := map[string]interface{}{"path": p, "bytes": written}
+	if err != nil {
+		details["error"] = err.Error()
+		audit.Log(target.auditEvent(auditEventFileDownload, details))
+		log.Printf("파일 다운로드 중단 (%s/%s %s): %v", target.Namespace, target.Pod, p, err)
+		// 잘린 응답이 정상 완료로 보이지 않도록 연결 종료
+		if conn, _, err := c.Writer.Hijack(); err == nil {
+			conn.Close()
+		}
+		return
+	}
+	if result.err != nil {
+		// 일부 항목을 읽지 못한 경우 (권한 등). 나머지는 이미 전송됨
+		details["warning"] = result.stderr
+		log.Printf("파일 다운로드 경고 (%s/%s %s): %s", target.Namespace, target.Pod, p, result.stderr)
+	}
+	audit.Log(target.auditEvent(auditEventFileDownload, details))
+}
+
+// 항목 크기 합계가 제한을 넘으면 errFileTooLarge
+func copyTarLimited(w io.Writer, tr *tar.Reader, first *tar.Header, maxBytes int64) (int64, error) {
+	tw := tar.NewWriter(w)
+	var total int64
+	for hdr := first; ; {
+		total += hdr.Size
+		if total > maxBytes {
+			return total, errFileTooLarge
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return total, err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return total, err
+		}
+		var err error
+		if hdr, err = tr.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return total, err
+		}
+	}
+	return total, tw.Close()
+}
+
+// 링크 대상을 archive 루트 기준 경로로 해석. 루트 밖으로 나가거나 앞서 만든 심볼릭 링크를 거쳐야 하면 false
+func resolveTarLink(dir, linkname string, symlinks map[string]bool) (string, bool) {
+	if linkname == "" || path.IsAbs(linkname) {
+		return "", false
+	}
+	var parts []string
+	if dir != "." {
+		parts = strings.Split(dir, "/")
+	}
+	components := strings.Split(linkname, "/")
+	for i, component := range components {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			if len(parts) == 0 {
+				return "", false
+			}
+			parts = parts[:len(parts)-1]
+			continue
+		}
+		parts = append(parts, component)
+		// 심볼릭 링크 너머는 컨테이너에서 실제로 어디를 가리킬지 알 수 없음
+		if symlinks[strings.Join(parts, "/")] && i < len(components)-1 {
+			return "", false
+		}
+	}
+	return strings.Join(parts, "/"), true
+}
+
+// 경로 자체 또는 상위 경로가 앞서 만든 심볼릭 링크인지 확인
+func throughTarSymlink(name string, symlinks map[string]bool) bool {
+	for p := name; p != "." && p != "/"; p = path.Dir(p) {
+		if symlinks[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// 업로드 tar 검증. 절대 경로, 상위 경로(..), 장치 파일 항목과
+// 대상 디렉터리 밖을 가리키는 링크, 같은 archive 의 심볼릭 링크를 거쳐 쓰는 항목은 거부
+func sanitizeTar(w io.Writer, r io.Reader, maxBytes int64) (int64, int, error) {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	var total int64
+	var entries int
+	symlinks := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, entries, fmt.Errorf("invalid tar archive: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(hdr.Name) || name == ".." || strings.HasPrefix(name, "../") {
+			return total, entries, fmt.Errorf("invalid tar entry: %q", hdr.Name)
+		}
+		if throughTarSymlink(name, symlinks) {
+			return total, entries, fmt.Errorf("invalid tar entry: %q is written through a symlink", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeDir:
+		case tar.TypeSymlink:
+			// 심볼릭 링크 대상은 링크가 있는 디렉터리 기준
+			if _, ok := resolveTarLink(path.Dir(name), hdr.Linkname, symlinks); !ok {
+				return total, entries, fmt.Errorf("invalid tar entry: symlink %q points outside the destination", hdr.Name)
+			}
+			symlinks[name] = true
+		case tar.TypeLink:
+			// 하드 링크 대상은 archive 루트 기준
+			if _, ok := resolveTarLink(".", hdr.Linkname, symlinks); !ok {
+				return total, entries, fmt.Errorf("invalid tar entry: hard link %q points outside the destination", hdr.Name)
+			}
+		default:
+			return total, entries, fmt.Errorf("unsupported tar entry type for %q", hdr.Name)
+		}
+		total += hdr.Size
+		if total > maxBytes {
+			return total, entries, errFileTooLarge
+		}
+		hdr.Uname, hdr.Gname = "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return total, entries, err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return total, entries, err
+		}
+		entries++
+	}
+	return total, entries, tw.Close()
+}
+
+// 단일 파일을 tar 항목 하나로 감쌈
+func writeSingleFileTar(w io.Writer, name string, mode int64, size int64, r io.Reader) error {
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// 업로드 본문. multipart 는 file 필드, 그 외에는 본문 전체
+func uploadSource(c *gin.Context) (io.Reader, string, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, "", nil
+	}
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", errors.New("multipart field \"file\" is required")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), nil
+		}
+	}
+}
+
+// 크기를 알 수 없는 본문은 임시 파일에 받아 tar 헤더에 쓸 크기를 확정
+func spoolUpload(r io.Reader, maxBytes int64) (*os.File, int64, error) {
+	tmp, err := os.CreateTemp("", "cp-upload-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(r, maxBytes+1))
+	if err == nil && n > maxBytes {
+		err = errFileTooLarge
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		return nil, n, err
+	}
+	return tmp, n, nil
+}
+
+// POST /files/upload?path=. application/x-tar 는 path 디렉터리에 풀고, 그 외에는 path 파일로 저장
+// (path 가 / 로 끝나면 multipart 파일 이름 사용)
+func UploadFileHandler(c *gin.Context) {
+	rawPath := c.Query("path")
+	p, err := containerPath(rawPath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mode := int64(0644)
+	if value := c.Query("mode"); value != "" {
+		if mode, err = strconv.ParseInt(value, 8, 32); err != nil || mode < 0 || mode > 07777 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mode: " + value})
+			return
+		}
+	}
+	isTar := c.ContentType() == "application/x-tar"
+	source, filename, err := uploadSource(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dir, name := p, ""
+	if !isTar {
+		dir, name = path.Dir(p), path.Base(p)
+		if strings.HasSuffix(rawPath, "/") || p == "/" {
+			dir, name = p, path.Base(filename)
+		}
+		if name == "" || name == "." || name == "/" || name == ".." {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file name is required"})
+			return
+		}
+	}
+
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	maxBytes := fileUploadMaxBytes()
+	var size int64
+	var entries int
+	var prepare func(w io.Writer) error
+	if isTar {
+		prepare = func(w io.Writer) error {
+			var err error
+			size, entries, err = sanitizeTar(w, source, maxBytes)
+			return err
+		}
+	} else {
+		var body io.Reader = source
+		size = c.Request.ContentLength
+		if filename != "" || size < 0 {
+			tmp, n, err := spoolUpload(source, maxBytes)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, errFileTooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			defer tmp.Close()
+			body, size = tmp, n
+		}
+		if size > maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("upload is %d bytes; the upload limit is %d bytes", size, maxBytes)})
+			return
+		}
+		entries = 1
+		prepare = func(w io.Writer) error {
+			return writeSingleFileTar(w, name, mode, size, body)
+		}
+	}
+
+	startedAt := time.Now()
+	pr, pw := io.Pipe()
+	prepared := make(chan error, 1)
+	go func() {
+		err := prepare(pw)
+		pw.CloseWithError(err)
+		prepared <- err
+	}()
+	stderr, err := target.exec(c.Request.Context(), tarExtractCommand(dir), pr, io.Discard)
+	pr.CloseWithError(io.ErrClosedPipe)
+	prepareErr := <-prepared
+
+	destination := dir
+	if name != "" {
+		destination = path.Join(dir, name)
+	}
+	details := map[string]interface{}{"path": destination, "bytes": size}
+	switch {
+	case prepareErr != nil && !errors.Is(prepareErr, io.ErrClosedPipe):
+		details["error"] = prepareErr.Error()
+		audit.Log(target.auditEvent(auditEventFileUpload, details))
+		status := http.StatusBadRequest
+		if errors.Is(prepareErr, errFileTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": prepareErr.Error()})
+	case err != nil:
+		code, message := target.execError("tar", stderr, startedAt, err)
+		details["error"] = message
+		audit.Log(target.auditEvent(auditEventFileUpload, details))
+		c.JSON(code, gin.H{"error": message})
+	default:
+		audit.Log(target.auditEvent(auditEventFileUpload, details))
+		c.JSON(http.StatusOK, gin.H{"path": destination, "bytes": size, "entries": entries})
+	}
+}
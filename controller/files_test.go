@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// --- [ 파일 업로드/다운로드 테스트 ] ---
+
+type testTarEntry struct {
+	name     string
+	body     string
+	typeflag byte
+	linkname string
+}
+
+func buildTestTar(t *testing.T, entries ...testTarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: typeflag, Linkname: e.linkname}))
+		_, err := tw.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func readTestTar(t *testing.T, r io.Reader) map[string]string {
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(body)
+	}
+}
+
+func TestContainerPath(t *testing.T) {
+	p, err := containerPath("/app//logs/../conf/")
+	require.NoError(t, err)
+	assert.Equal(t, "/app/conf", p)
+
+	for _, invalid := range []string{"", "app/conf", "../etc", "/app\x00"} {
+		_, err := containerPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+	assert.Equal(t, []string{"tar", "cf", "-", "-C", "/app", "./-v"}, tarCreateCommand("/app/-v"))
+	assert.Equal(t, []string{"tar", "cf", "-", "-C", "/", "."}, tarCreateCommand("/"))
+}
+
+func TestSanitizeTar(t *testing.T) {
+	var out bytes.Buffer
+	size, entries, err := sanitizeTar(&out, bytes.NewReader(buildTestTar(t,
+		testTarEntry{name: "conf/", typeflag: tar.TypeDir},
+		testTarEntry{name: "conf/app.yaml", body: "port: 8080"},
+	)), 1024)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	assert.Equal(t, 2, entries)
+	assert.Equal(t, map[string]string{"conf/": "", "conf/app.yaml": "port: 8080"}, readTestTar(t, &out))
+
+	for _, entry := range []testTarEntry{
+		{name: "/etc/passwd", body: "x"},
+		{name: "../../etc/passwd", body: "x"},
+		{name: "dev/null", typeflag: tar.TypeChar},
+	} {
+		_, _, err := sanitizeTar(io.Discard, bytes.NewReader(buildTestTar(t, entry)), 1024)
+		assert.Error(t, err, entry.name)
+	}
+
+	_, _, err = sanitizeTar(io.Discard, bytes.NewReader(buildTestTar(t, testTarEntry{name: "big", body: "0123456789"})), 5)
+	assert.ErrorIs(t, err, errFileTooLarge)
+}
+
+// TestSanitizeTar_Links: 대상 디렉터리 밖을 가리키거나 심볼릭 링크를 거쳐 쓰는 항목은 거부
+func TestSanitizeTar_Links(t *testing.T) {
+	_, entries, err := sanitizeTar(io.Discard, bytes.NewReader(buildTestTar(t,
+		testTarEntry{name: "conf/", typeflag: tar.TypeDir},
+		testTarEntry{name: "conf/app.yaml", body: "port: 8080"},
+		testTarEntry{name: "conf/current", typeflag: tar.TypeSymlink, linkname: "app.yaml"},
+		testTarEntry{name: "logs", typeflag: tar.TypeSymlink, linkname: "./conf/../var/log"},
+		testTarEntry{name: "app.yaml", typeflag: tar.TypeLink, linkname: "conf/app.yaml"},
+	)), 1024)
+	require.NoError(t, err)
+	assert.Equal(t, 5, entries)
+
+	tests := []struct {
+		name    string
+		entries []testTarEntry
+	}{
+		{"Absolute symlink", []testTarEntry{{name: "x", typeflag: tar.TypeSymlink, linkname: "/etc"}}},
+		{"Symlink escapes", []testTarEntry{{name: "conf/x", typeflag: tar.TypeSymlink, linkname: "../../etc"}}},
+		{"Absolute hard link", []testTarEntry{{name: "x", typeflag: tar.TypeLink, linkname: "/etc/shadow"}}},
+		{"Hard link escapes", []testTarEntry{{name: "conf/x", typeflag: tar.TypeLink, linkname: "../etc/shadow"}}},
+		{"Write through symlink", []testTarEntry{
+			{name: "x", typeflag: tar.TypeSymlink, linkname: "conf"},
+			{name: "x/passwd", body: "root::0:0::/root:/bin/sh"},
+		}},
+		{"Replace symlink", []testTarEntry{
+			{name: "x", typeflag: tar.TypeSymlink, linkname: "conf"},
+			{name: "x/", typeflag: tar.TypeDir},
+		}},
+		{"Link target through symlink", []testTarEntry{
+			{name: "deep/", typeflag: tar.TypeDir},
+			{name: "deep/s", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "l", typeflag: tar.TypeSymlink, linkname: "deep/s/../.."},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := sanitizeTar(io.Discard, bytes.NewReader(buildTestTar(t, tt.entries...)), 1024)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFileHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{FileDownloadMaxBytes: 64, FileUploadMaxBytes: 64}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) { events = append(events, event) })
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+	// 컨테이너의 tar 동작을 흉내 냄
+	var command []string
+	var stream func(options remotecommand.StreamOptions) error
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		command = opts.Command
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			return stream(options)
+		}}, nil
+	})
+	notFound := func(options remotecommand.StreamOptions) error {
+		io.WriteString(options.Stderr, "tar: ./missing: Cannot stat: No such file or directory")
+		return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 2}
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "file-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/files/download", DownloadFileHandler)
+	r.POST("/files/upload", UploadFileHandler)
+	const query = "pod=p1&namespace=ns1&container=app&clusterId=c1"
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		events = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	download := func(params string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/files/download?"+query+"&"+params, nil)
+		return serve(req)
+	}
+	upload := func(params, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/files/upload?"+query+"&"+params, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return serve(req)
+	}
+
+	t.Run("Download - Single file", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			_, err := options.Stdout.Write(buildTestTar(t, testTarEntry{name: "./app.log", body: "started"}))
+			return err
+		}
+		w := download("path=/var/log/app.log")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"tar", "cf", "-", "-C", "/var/log", "./app.log"}, command)
+		assert.Equal(t, "started", w.Body.String())
+		assert.Equal(t, "7", w.Header().Get("Content-Length"))
+		assert.Equal(t, "attachment; filename=app.log", w.Header().Get("Content-Disposition"))
+		require.Len(t, events, 1)
+		assert.Equal(t, auditEventFileDownload, events[0].Type)
+		assert.Equal(t, int64(7), events[0].Details["bytes"])
+	})
+
+	t.Run("Download - Directory as tar", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			_, err := options.Stdout.Write(buildTestTar(t,
+				testTarEntry{name: "./conf/", typeflag: tar.TypeDir},
+				testTarEntry{name: "./conf/app.yaml", body: "port: 8080"},
+			))
+			return err
+		}
+		w := download("path=/app/conf")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-tar", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=conf.tar", w.Header().Get("Content-Disposition"))
+		assert.Equal(t, map[string]string{"./conf/": "", "./conf/app.yaml": "port: 8080"}, readTestTar(t, w.Body))
+	})
+
+	t.Run("Download - Symlink", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			_, err := options.Stdout.Write(buildTestTar(t, testTarEntry{name: "./current", typeflag: tar.TypeSymlink, linkname: "releases/v2"}))
+			return err
+		}
+		w := download("path=/app/current")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "releases/v2")
+	})
+
+	t.Run("Download - File over limit", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			_, err := options.Stdout.Write(buildTestTar(t, testTarEntry{name: "./heap.hprof", body: string(make([]byte, 100))}))
+			return err
+		}
+		assert.Equal(t, http.StatusRequestEntityTooLarge, download("path=/tmp/heap.hprof").Code)
+	})
+
+	t.Run("Download - Not found", func(t *testing.T) {
+		stream = notFound
+		w := download("path=/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "No such file or directory")
+		require.Len(t, events, 1)
+		assert.NotEmpty(t, events[0].Details["error"])
+	})
+
+	t.Run("Download - Container without tar", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			return errors.New(`exec: "tar": executable file not found in $PATH`)
+		}
+		w := download("path=/app")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "tar is not available in the container")
+	})
+
+	t.Run("Download - Invalid request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, download("path=relative/path").Code)
+		assert.Equal(t, http.StatusBadRequest, download("path=/app&format=zip").Code)
+	})
+
+	var received []byte
+	extract := func(options remotecommand.StreamOptions) error {
+		var err error
+		received, err = io.ReadAll(options.Stdin)
+		return err
+	}
+
+	t.Run("Upload - Raw body", func(t *testing.T) {
+		stream = extract
+		w := upload("path=/app/conf/app.yaml&mode=600", "application/octet-stream", bytes.NewBufferString("port: 9090"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"path":"/app/conf/app.yaml","bytes":10,"entries":1}`, w.Body.String())
+		assert.Equal(t, []string{"tar", "xof", "-", "-C", "/app/conf"}, command)
+
+		hdr, err := tar.NewReader(bytes.NewReader(received)).Next()
+		require.NoError(t, err)
+		assert.Equal(t, "app.yaml", hdr.Name)
+		assert.Equal(t, int64(0600), hdr.Mode)
+		assert.Equal(t, map[string]string{"app.yaml": "port: 9090"}, readTestTar(t, bytes.NewReader(received)))
+		require.Len(t, events, 1)
+		assert.Equal(t, auditEventFileUpload, events[0].Type)
+	})
+
+	t.Run("Upload - Multipart into directory", func(t *testing.T) {
+		stream = extract
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "dump.sql")
+		io.WriteString(part, "select 1;")
+		mw.Close()
+
+		w := upload("path=/tmp/", mw.FormDataContentType(), &body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"path":"/tmp/dump.sql","bytes":9,"entries":1}`, w.Body.String())
+		assert.Equal(t, map[string]string{"dump.sql": "select 1;"}, readTestTar(t, bytes.NewReader(received)))
+	})
+
+	t.Run("Upload - Tar archive", func(t *testing.T) {
+		stream = extract
+		archive := buildTestTar(t, testTarEntry{name: "static/index.html", body: "<html>"})
+		w := upload("path=/usr/share/nginx", "application/x-tar", bytes.NewReader(archive))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"path":"/usr/share/nginx","bytes":6,"entries":1}`, w.Body.String())
+		assert.Equal(t, []string{"tar", "xof", "-", "-C", "/usr/share/nginx"}, command)
+		assert.Equal(t, map[string]string{"static/index.html": "<html>"}, readTestTar(t, bytes.NewReader(received)))
+	})
+
+	t.Run("Upload - Unsafe tar entry", func(t *testing.T) {
+		stream = extract
+		archive := buildTestTar(t, testTarEntry{name: "../../etc/cron.d/job", body: "* * * * * root sh"})
+		w := upload("path=/app", "application/x-tar", bytes.NewReader(archive))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid tar entry")
+	})
+
+	t.Run("Upload - Over limit", func(t *testing.T) {
+		stream = extract
+		w := upload("path=/tmp/big.bin", "application/octet-stream", bytes.NewReader(make([]byte, 100)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("Upload - Read-only file system", func(t *testing.T) {
+		stream = func(options remotecommand.StreamOptions) error {
+			io.Copy(io.Discard, options.Stdin)
+			io.WriteString(options.Stderr, "tar: app.yaml: Cannot open: Read-only file system")
+			return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 2}
+		}
+		w := upload("path=/app/app.yaml", "application/octet-stream", bytes.NewBufferString("x"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Upload - Invalid request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, upload("path=app.yaml", "", bytes.NewBufferString("x")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload("path=/app.yaml&mode=999", "", bytes.NewBufferString("x")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload("path=/tmp/", "", bytes.NewBufferString("x")).Code)
+	})
+}
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"cp-remote-access-api/internal/audit"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// 한 프레임으로 보내는 최대 로그 줄 길이. 더 긴 줄은 나누어 전송
+	maxLogLineBytes = 64 * 1024
+
+	auditEventLogStream = "logs.stream"
+)
+
+// follow(기본 true), tailLines, sinceSeconds, timestamps, previous, container
+func podLogOptionsFromQuery(c *gin.Context) (*corev1.PodLogOptions, error) {
+	options := &corev1.PodLogOptions{Container: c.Query("container"), Follow: true}
+
+	for param, target := range map[string]*bool{"follow": &options.Follow, "timestamps": &options.Timestamps, "previous": &options.Previous} {
+		if value := c.Query(param); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", param, value)
+			}
+			*target = parsed
+		}
+	}
+	for param, target := range map[string]**int64{"tailLines": &options.TailLines, "sinceSeconds": &options.SinceSeconds} {
+		if value := c.Query(param); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 0 || (param == "sinceSeconds" && parsed == 0) {
+				return nil, fmt.Errorf("invalid %s: %s", param, value)
+			}
+			*target = &parsed
+		}
+	}
+	return options, nil
+}
+
+// 줄 단위로 읽어 fn 에 전달. maxLogLineBytes 를 넘는 줄은 나누어 전달하며 줄바꿈은 제거
+func readLogLines(r io.Reader, fn func(line []byte) error) error {
+	reader := bufio.NewReaderSize(r, maxLogLineBytes)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+			if len(line) > 0 && line[len(line)-1] == '\r' {
+				line = line[:len(line)-1]
+			}
+		}
+		if len(line) > 0 || err == nil {
+			if fnErr := fn(line); fnErr != nil {
+				return fnErr
+			}
+		}
+		switch {
+		case err == nil, errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+func logAuditDetails(selector, workload string) map[string]interface{} {
+	switch {
+	case selector != "":
+		return map[string]interface{}{"selector": selector}
+	case workload != "":
+		return map[string]interface{}{"workload": workload}
+	}
+	return nil
+}
+
+func LogsWebSocketHandler(c *gin.Context) {
+	var pod = c.Query("pod")
+	var namespace = c.Query("namespace")
+	var clusterId = c.Query("clusterId")
+	var selectorQuery = c.Query("selector")
+	var workload = c.Query("workload")
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+
+	options, err := podLogOptionsFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// pod 대신 selector 또는 workload 를 지정하면 여러 파드의 로그를 합쳐서 전송
+	multiPod := selectorQuery != "" || workload != ""
+	var selector labels.Selector
+	switch {
+	case pod != "" && (selectorQuery != "" || workload != ""):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pod cannot be used with selector or workload"})
+		return
+	case selectorQuery != "" && workload != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "selector and workload cannot be used together"})
+		return
+	case selectorQuery != "":
+		if selector, err = labels.Parse(selectorQuery); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid selector: " + err.Error()})
+			return
+		}
+	case workload != "":
+		if _, _, err = parseWorkload(workload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Failed to create clientset"))
+		return
+	}
+
+	wsStream := newWebSocketStream(conn)
+	wsStream.discardStdin()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-wsStream.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	audit.Log(audit.Event{
+		Type:      auditEventLogStream,
+		User:      claims["userAuthId"].(string),
+		UserType:  claims["userType"].(string),
+		Cluster:   clusterId,
+		Namespace: namespace,
+		Pod:       pod,
+		Container: options.Container,
+		ClientIP:  c.ClientIP(),
+		Details:   logAuditDetails(selectorQuery, workload),
+	})
+
+	startedAt := time.Now()
+	if multiPod {
+		if workload != "" {
+			if selector, err = workloadSelector(ctx, clientset, namespace, workload); err != nil {
+				wsStream.writeExit(describeExecExit(nil, namespace, "", "", startedAt, err))
+				return
+			}
+		}
+		err = newLogTail(clientset, wsStream, namespace, selector, *options).run(ctx)
+		if wsStream.closed() {
+			return
+		}
+		if err != nil {
+			wsStream.writeExit(describeExecExit(nil, namespace, "", options.Container, startedAt, err))
+			return
+		}
+		wsStream.writeExit(execExit{Type: controlTypeExit, Reason: exitReasonCompleted, Message: "log stream ended"})
+		return
+	}
+
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, options.Container, startedAt, err))
+		return
+	}
+	defer stream.Close()
+
+	err = readLogLines(stream, func(line []byte) error {
+		return wsStream.writeFrame(remotecommandconsts.StreamStdOut, line)
+	})
+	if wsStream.closed() {
+		return
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, options.Container, startedAt, err))
+		return
+	}
+	wsStream.writeExit(execExit{Type: controlTypeExit, Reason: exitReasonCompleted, Message: "log stream ended"})
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// --- [ LogsWebSocketHandler 테스트 ] ---
+
+func TestPodLogOptionsFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name     string
+		query    string
+		expected *corev1.PodLogOptions
+		errMsg   string
+	}{
+		{"Defaults", "", &corev1.PodLogOptions{Follow: true}, ""},
+		{"All options", "?container=app&follow=false&tailLines=100&sinceSeconds=60&timestamps=true&previous=true",
+			&corev1.PodLogOptions{Container: "app", Follow: false, TailLines: int64Ptr(100), SinceSeconds: int64Ptr(60), Timestamps: true, Previous: true}, ""},
+		{"Zero tail lines", "?tailLines=0", &corev1.PodLogOptions{Follow: true, TailLines: int64Ptr(0)}, ""},
+		{"Invalid bool", "?follow=maybe", nil, "invalid follow: maybe"},
+		{"Negative tail lines", "?tailLines=-1", nil, "invalid tailLines: -1"},
+		{"Zero since seconds", "?sinceSeconds=0", nil, "invalid sinceSeconds: 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/ws/logs"+tt.query, nil)
+
+			options, err := podLogOptionsFromQuery(c)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, options)
+		})
+	}
+}
+
+func TestReadLogLines(t *testing.T) {
+	long := strings.Repeat("x", maxLogLineBytes+10)
+	input := "first\r\nsecond\n\n" + long + "\nlast"
+
+	var lines []string
+	err := readLogLines(strings.NewReader(input), func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "", long[:maxLogLineBytes], long[maxLogLineBytes:], "last"}, lines)
+
+	// 전송 실패 시 즉시 중단
+	calls := 0
+	err = readLogLines(strings.NewReader("a\nb\n"), func(line []byte) error {
+		calls++
+		return errStreamClosed
+	})
+	assert.True(t, errors.Is(err, errStreamClosed))
+	assert.Equal(t, 1, calls)
+}
+
+func TestLogsWebSocketHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) { events = append(events, event) })
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		assert.Equal(t, "ns1", ns)
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/ws/logs", LogsWebSocketHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "http", "ws", 1) + "/ws/logs"
+
+	t.Run("Success - Lines then completion", func(t *testing.T) {
+		events = nil
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=app&namespace=ns1&clusterId=c1&container=main&tailLines=10", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// fake clientset 의 로그 본문
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "fake logs", string(msg))
+
+		_, msg, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"exit","reason":"Completed","message":"log stream ended"}`, string(msg))
+
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+		require.Len(t, events, 1)
+		assert.Equal(t, auditEventLogStream, events[0].Type)
+		assert.Equal(t, "ws-user", events[0].User)
+		assert.Equal(t, "main", events[0].Container)
+	})
+
+	t.Run("Failure - Invalid option", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?pod=app&namespace=ns1&sinceSeconds=abc", nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Failure - Pod with selector or workload", func(t *testing.T) {
+		for _, query := range []string{"&selector=app%3Dweb", "&workload=deployment/web"} {
+			_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?pod=app&namespace=ns1"+query, nil)
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
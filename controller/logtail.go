@@ -0,0 +1,496 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"cp-remote-access-api/config"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	controlTypeLogSource = "logSource"
+	logSourceAdded       = "added"
+	logSourceRemoved     = "removed"
+
+	defaultLogMaxStreams = 50
+	// 여러 스트림의 줄을 시간순으로 정렬하기 위해 보관하는 시간
+	logMergeWindow        = 250 * time.Millisecond
+	logMergeFlushInterval = 100 * time.Millisecond
+	logMergeMaxPending    = 10000
+	logRewatchDelay       = time.Second
+)
+
+// 로그 스트림 추가/제거 알림
+type logSourceMessage struct {
+	Type      string `json:"type"`
+	Event     string `json:"event"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+func logMaxStreams() int {
+	if config.Env != nil && config.Env.LogMaxStreams > 0 {
+		return config.Env.LogMaxStreams
+	}
+	return defaultLogMaxStreams
+}
+
+// 컨테이너 로그 스트림 열기
+var streamPodLogs = func(ctx context.Context, clientset kubernetes.Interface, namespace, pod string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+	return clientset.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+}
+
+// workload 파라미터 (kind/name) 의 kind 정규화
+var workloadKinds = map[string]string{
+	"deployment": "deployment", "deployments": "deployment", "deploy": "deployment",
+	"statefulset": "statefulset", "statefulsets": "statefulset", "sts": "statefulset",
+	"daemonset": "daemonset", "daemonsets": "daemonset", "ds": "daemonset",
+	"replicaset": "replicaset", "replicasets": "replicaset", "rs": "replicaset",
+	"job": "job", "jobs": "job",
+}
+
+func parseWorkload(workload string) (string, string, error) {
+	kind, name, found := strings.Cut(workload, "/")
+	if !found || name == "" || workloadKinds[strings.ToLower(kind)] == "" {
+		return "", "", fmt.Errorf("invalid workload: %s", workload)
+	}
+	return workloadKinds[strings.ToLower(kind)], name, nil
+}
+
+// 워크로드의 spec.selector 를 파드 셀렉터로 변환
+func workloadSelector(ctx context.Context, clientset kubernetes.Interface, namespace, workload string) (labels.Selector, error) {
+	kind, name, err := parseWorkload(workload)
+	if err != nil {
+		return nil, err
+	}
+	var selector *metav1.LabelSelector
+	switch kind {
+	case "deployment":
+		obj, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = obj.Spec.Selector
+	case "statefulset":
+		obj, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = obj.Spec.Selector
+	case "daemonset":
+		obj, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = obj.Spec.Selector
+	case "replicaset":
+		obj, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = obj.Spec.Selector
+	case "job":
+		obj, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = obj.Spec.Selector
+	}
+	if selector == nil {
+		return nil, fmt.Errorf("%s %s has no selector", kind, name)
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+type logEntry struct {
+	source  string
+	time    time.Time
+	hasTime bool
+	line    []byte
+	arrived time.Time
+}
+
+// kubelet 이 붙인 RFC3339 타임스탬프 분리. 없으면 도착 시각 사용
+func newLogEntry(source string, line []byte, arrived time.Time) logEntry {
+	entry := logEntry{source: source, time: arrived, line: append([]byte(nil), line...), arrived: arrived}
+	if idx := bytes.IndexByte(line, ' '); idx > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, string(line[:idx])); err == nil {
+			entry.time, entry.hasTime = ts, true
+			entry.line = entry.line[idx+1:]
+		}
+	}
+	return entry
+}
+
+func (e logEntry) format() []byte {
+	if !e.hasTime {
+		return []byte(fmt.Sprintf("[%s] %s", e.source, e.line))
+	}
+	return []byte(fmt.Sprintf("[%s] %s %s", e.source, e.time.UTC().Format(time.RFC3339Nano), e.line))
+}
+
+// 여러 스트림의 줄을 잠시 모아 타임스탬프 순으로 내보냄.
+// 전송이 한 번 실패하면 이후의 줄은 버리고 같은 오류를 반환
+type logMerger struct {
+	mu      sync.Mutex
+	pending []logEntry
+	window  time.Duration
+	emit    func(logEntry) error
+	err     error
+}
+
+func (m *logMerger) add(entry logEntry) error {
+	m.mu.Lock()
+	if m.err != nil {
+		m.mu.Unlock()
+		return m.err
+	}
+	m.pending = append(m.pending, entry)
+	full := len(m.pending) >= logMergeMaxPending
+	m.mu.Unlock()
+	if full {
+		return m.flush(entry.arrived)
+	}
+	return nil
+}
+
+// cutoff 이전에 도착한 줄을 시간순으로 전송
+func (m *logMerger) flush(cutoff time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+
+	var ready, rest []logEntry
+	for _, entry := range m.pending {
+		if entry.arrived.After(cutoff) {
+			rest = append(rest, entry)
+		} else {
+			ready = append(ready, entry)
+		}
+	}
+	m.pending = rest
+	sort.SliceStable(ready, func(i, j int) bool { return ready[i].time.Before(ready[j].time) })
+	for _, entry := range ready {
+		if err := m.emit(entry); err != nil {
+			m.err, m.pending = err, nil
+			return err
+		}
+	}
+	return nil
+}
+
+// 셀렉터에 맞는 파드의 컨테이너 로그를 동시에 따라가며 하나의 스트림으로 합침
+type logTail struct {
+	clientset kubernetes.Interface
+	namespace string
+	selector  labels.Selector
+	container string
+	options   corev1.PodLogOptions
+	wsStream  *webSocketStream
+	merger    *logMerger
+	stop      context.CancelFunc
+
+	mu sync.Mutex
+	// 진행 중인 스트림. 키는 pod/container#restartCount
+	followers map[string]context.CancelFunc
+	// 클라이언트에 알린 소스(pod/container)별 처음 본 재시작 횟수
+	restarts map[string]int32
+	// 끊긴 스트림을 다시 따라갈 때 이어서 읽을 마지막 줄의 시각
+	resume map[string]time.Time
+	active int
+	wg     sync.WaitGroup
+}
+
+func newLogTail(clientset kubernetes.Interface, wsStream *webSocketStream, namespace string, selector labels.Selector, options corev1.PodLogOptions) *logTail {
+	t := &logTail{
+		clientset: clientset,
+		namespace: namespace,
+		selector:  selector,
+		container: options.Container,
+		options:   options,
+		wsStream:  wsStream,
+		followers: map[string]context.CancelFunc{},
+		restarts:  map[string]int32{},
+		resume:    map[string]time.Time{},
+	}
+	// 정렬을 위해 항상 타임스탬프 요청
+	t.options.Timestamps = true
+	t.merger = &logMerger{window: logMergeWindow, emit: func(entry logEntry) error {
+		return wsStream.writeFrame(remotecommandconsts.StreamStdOut, entry.format())
+	}}
+	return t
+}
+
+// follow 이면 파드 변화를 감시하며 클라이언트가 끊을 때까지, 아니면 현재 파드의 로그를 모두 보낸 뒤 반환
+func (t *logTail) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 전송 실패 시 모든 스트림과 watch 를 중단
+	t.stop = cancel
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(logMergeFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := t.merger.flush(now.Add(-t.merger.window)); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	var err error
+	if t.options.Follow {
+		err = t.watch(ctx)
+		t.stopAll()
+	} else {
+		_, err = t.list(ctx)
+	}
+	t.wg.Wait()
+	cancel()
+	<-flushed
+	if flushErr := t.merger.flush(time.Now().Add(time.Hour)); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// 현재 파드의 로그 스트림을 시작하고, 목록에서 사라진 파드의 스트림은 정리
+func (t *logTail) list(ctx context.Context) (string, error) {
+	pods, err := t.clientset.CoreV1().Pods(t.namespace).List(ctx, metav1.ListOptions{LabelSelector: t.selector.String()})
+	if err != nil {
+		return "", err
+	}
+	current := map[string]bool{}
+	for i := range pods.Items {
+		current[pods.Items[i].Name] = true
+		t.syncPod(ctx, &pods.Items[i])
+	}
+	for _, name := range t.knownPods() {
+		if !current[name] {
+			t.removePod(name)
+		}
+	}
+	return pods.ResourceVersion, nil
+}
+
+// 목록 조회 후 그 시점부터 watch. watch 가 만료되면 다시 목록부터 시작하며 이미 따라가는 컨테이너는 중복 시작하지 않음
+func (t *logTail) watch(ctx context.Context) error {
+	for {
+		resourceVersion, err := t.list(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		w, err := t.clientset.CoreV1().Pods(t.namespace).Watch(ctx, metav1.ListOptions{LabelSelector: t.selector.String(), ResourceVersion: resourceVersion})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		t.consume(ctx, w)
+		w.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logRewatchDelay):
+		}
+	}
+}
+
+func (t *logTail) consume(ctx context.Context, w watch.Interface) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			pod, isPod := event.Object.(*corev1.Pod)
+			if !isPod || !t.selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				t.syncPod(ctx, pod)
+			case watch.Deleted:
+				t.removePod(pod.Name)
+			}
+		}
+	}
+}
+
+// 시작된 컨테이너마다 로그 스트림 시작. 재시작된 컨테이너는 새 인스턴스의 시작 시각부터 이어서 읽음
+func (t *logTail) syncPod(ctx context.Context, pod *corev1.Pod) {
+	if pod.DeletionTimestamp != nil {
+		return
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if t.container != "" && status.Name != t.container {
+			continue
+		}
+		started := status.State.Running != nil || (!t.options.Follow && status.State.Terminated != nil)
+		if !started {
+			continue
+		}
+		source := pod.Name + "/" + status.Name
+		key := fmt.Sprintf("%s#%d", source, status.RestartCount)
+
+		t.mu.Lock()
+		if _, exists := t.followers[key]; exists {
+			t.mu.Unlock()
+			continue
+		}
+		if t.active >= logMaxStreams() {
+			t.mu.Unlock()
+			t.wsStream.writeNotice(fmt.Sprintf("Log stream limit (%d) reached. %s is not followed.", logMaxStreams(), source))
+			continue
+		}
+		options := t.options
+		options.Container = status.Name
+		if first, seen := t.restarts[source]; seen && first != status.RestartCount && status.State.Running != nil {
+			since := status.State.Running.StartedAt
+			options.TailLines, options.SinceSeconds, options.SinceTime = nil, nil, &since
+		} else if !seen {
+			t.restarts[source] = status.RestartCount
+		}
+		after, resumed := t.resume[key]
+		if resumed {
+			since := metav1.NewTime(after)
+			options.TailLines, options.SinceSeconds, options.SinceTime = nil, nil, &since
+		}
+		followCtx, cancel := context.WithCancel(ctx)
+		t.followers[key] = cancel
+		t.active++
+		t.wg.Add(1)
+		t.mu.Unlock()
+
+		t.wsStream.writeJSON(logSourceMessage{Type: controlTypeLogSource, Event: logSourceAdded, Pod: pod.Name, Container: status.Name})
+		go t.follow(followCtx, key, pod.Name, source, options, after)
+	}
+}
+
+// after 가 있으면 그 시각 이전의 줄은 이미 보냈으므로 건너뜀 (since 는 초 단위)
+func (t *logTail) follow(ctx context.Context, key, pod, source string, options corev1.PodLogOptions, after time.Time) {
+	defer t.wg.Done()
+	last := after
+	stream, err := streamPodLogs(ctx, t.clientset, t.namespace, pod, &options)
+	if err == nil {
+		err = readLogLines(stream, func(line []byte) error {
+			entry := newLogEntry(source, line, time.Now())
+			if entry.hasTime {
+				if !after.IsZero() && !entry.time.After(after) {
+					return ctx.Err()
+				}
+				last = entry.time
+			}
+			if err := t.merger.add(entry); err != nil {
+				t.stop()
+				return err
+			}
+			return ctx.Err()
+		})
+		stream.Close()
+	}
+
+	// 제거되거나 중단된 스트림이 아니면 다음 syncPod 에서 다시 따라갈 수 있도록 정리
+	t.mu.Lock()
+	t.active--
+	stopped := ctx.Err() != nil
+	if !stopped {
+		delete(t.followers, key)
+		if !last.IsZero() {
+			t.resume[key] = last
+		}
+	}
+	t.mu.Unlock()
+	if err != nil && !stopped {
+		log.Printf("로그 스트림 실패 (%s/%s): %v", t.namespace, source, err)
+		t.wsStream.writeNotice(fmt.Sprintf("Log stream for %s failed: %v", source, err))
+	}
+}
+
+func (t *logTail) removePod(pod string) {
+	prefix := pod + "/"
+	removed := map[string]bool{}
+	t.mu.Lock()
+	for key, cancel := range t.followers {
+		if strings.HasPrefix(key, prefix) {
+			cancel()
+			delete(t.followers, key)
+		}
+	}
+	for key := range t.resume {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.resume, key)
+		}
+	}
+	// 스트림이 이미 끝난 소스도 제거를 알림
+	for source := range t.restarts {
+		if strings.HasPrefix(source, prefix) {
+			removed[strings.TrimPrefix(source, prefix)] = true
+			delete(t.restarts, source)
+		}
+	}
+	t.mu.Unlock()
+
+	containers := make([]string, 0, len(removed))
+	for container := range removed {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+	for _, container := range containers {
+		t.wsStream.writeJSON(logSourceMessage{Type: controlTypeLogSource, Event: logSourceRemoved, Pod: pod, Container: container})
+	}
+}
+
+// 클라이언트에 소스를 알린 파드 목록. 스트림이 끝난 파드도 포함
+func (t *logTail) knownPods() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seen := map[string]bool{}
+	var pods []string
+	for source := range t.restarts {
+		pod := strings.SplitN(source, "/", 2)[0]
+		if !seen[pod] {
+			seen[pod] = true
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func (t *logTail) stopAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cancel := range t.followers {
+		cancel()
+	}
+}
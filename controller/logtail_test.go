@@ -0,0 +1,307 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// --- [ 여러 파드 로그 병합 테스트 ] ---
+
+func runningLogPod(name string, labels map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", Labels: labels}}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:  container,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+// 첫 줄을 보낸 뒤 ctx 가 끝날 때까지 열려 있는 로그 스트림
+func openLogStream(ctx context.Context, line string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte(line + "\n"))
+		<-ctx.Done()
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestNewLogEntry(t *testing.T) {
+	arrived := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entry := newLogEntry("web-1/app", []byte("2025-01-01T09:00:00.123456789Z GET /healthz 200"), arrived)
+	assert.True(t, entry.hasTime)
+	assert.Equal(t, "[web-1/app] 2025-01-01T09:00:00.123456789Z GET /healthz 200", string(entry.format()))
+
+	entry = newLogEntry("web-1/app", []byte("no timestamp here"), arrived)
+	assert.False(t, entry.hasTime)
+	assert.Equal(t, arrived, entry.time)
+	assert.Equal(t, "[web-1/app] no timestamp here", string(entry.format()))
+}
+
+func TestLogMerger_Flush(t *testing.T) {
+	var emitted []string
+	merger := &logMerger{window: logMergeWindow, emit: func(entry logEntry) error {
+		emitted = append(emitted, string(entry.line))
+		return nil
+	}}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) time.Time { return base.Add(offset) }
+
+	// 도착 순서와 다른 타임스탬프 순으로 정렬
+	merger.add(logEntry{line: []byte("b"), time: at(2 * time.Millisecond), arrived: at(0)})
+	merger.add(logEntry{line: []byte("a"), time: at(1 * time.Millisecond), arrived: at(10 * time.Millisecond)})
+	merger.add(logEntry{line: []byte("c"), time: at(0), arrived: at(time.Second)})
+
+	require.NoError(t, merger.flush(at(100*time.Millisecond)))
+	assert.Equal(t, []string{"a", "b"}, emitted)
+
+	// 기간 밖의 줄은 다음 flush 까지 보관
+	require.NoError(t, merger.flush(at(2*time.Second)))
+	assert.Equal(t, []string{"a", "b", "c"}, emitted)
+}
+
+func TestLogMerger_EmitError(t *testing.T) {
+	var emitted int
+	merger := &logMerger{window: logMergeWindow, emit: func(entry logEntry) error {
+		emitted++
+		return websocket.ErrCloseSent
+	}}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < logMergeMaxPending-1; i++ {
+		require.NoError(t, merger.add(logEntry{line: []byte("x"), time: base, arrived: base}))
+	}
+	// 가득 차서 flush 할 때의 전송 실패를 반환하고, 이후에는 더 전송하지 않음
+	assert.ErrorIs(t, merger.add(logEntry{line: []byte("x"), time: base, arrived: base}), websocket.ErrCloseSent)
+	assert.ErrorIs(t, merger.add(logEntry{line: []byte("y"), time: base, arrived: base}), websocket.ErrCloseSent)
+	assert.ErrorIs(t, merger.flush(base.Add(time.Hour)), websocket.ErrCloseSent)
+	assert.Equal(t, 1, emitted)
+}
+
+func TestParseWorkload(t *testing.T) {
+	kind, name, err := parseWorkload("deploy/web")
+	require.NoError(t, err)
+	assert.Equal(t, "deployment", kind)
+	assert.Equal(t, "web", name)
+
+	kind, _, err = parseWorkload("StatefulSet/db")
+	require.NoError(t, err)
+	assert.Equal(t, "statefulset", kind)
+
+	for _, invalid := range []string{"web", "cronjob/web", "deployment/"} {
+		_, _, err := parseWorkload(invalid)
+		assert.EqualError(t, err, "invalid workload: "+invalid)
+	}
+}
+
+func TestWorkloadSelector(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+	})
+
+	selector, err := workloadSelector(context.Background(), clientset, "ns1", "deployment/web")
+	require.NoError(t, err)
+	assert.Equal(t, "app=web", selector.String())
+
+	_, err = workloadSelector(context.Background(), clientset, "ns1", "deployment/missing")
+	assert.Error(t, err)
+}
+
+func TestLogsWebSocketHandler_MultiPod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(audit.Log, func(event audit.Event) {})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/ws/logs", LogsWebSocketHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "http", "ws", 1) + "/ws/logs"
+
+	web := map[string]string{"app": "web"}
+	readMessage := func(t *testing.T, conn *websocket.Conn) string {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		return string(msg)
+	}
+
+	t.Run("Success - Snapshot of matching pods", func(t *testing.T) {
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset(
+			runningLogPod("web-1", web, "app"),
+			runningLogPod("web-2", web, "app", "proxy"),
+			runningLogPod("db-1", map[string]string{"app": "db"}, "db"),
+		)}
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?namespace=ns1&clusterId=c1&selector=app%3Dweb&container=app&follow=false", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var sources, lines []string
+		for {
+			msg := readMessage(t, conn)
+			if strings.HasPrefix(msg, "[") {
+				lines = append(lines, msg)
+				continue
+			}
+			var control map[string]string
+			require.NoError(t, json.Unmarshal([]byte(msg), &control))
+			if control["type"] == controlTypeExit {
+				assert.Equal(t, exitReasonCompleted, control["reason"])
+				break
+			}
+			assert.Equal(t, logSourceAdded, control["event"])
+			sources = append(sources, control["pod"]+"/"+control["container"])
+		}
+		sort.Strings(sources)
+		sort.Strings(lines)
+		assert.Equal(t, []string{"web-1/app", "web-2/app"}, sources)
+		assert.Equal(t, []string{"[web-1/app] fake logs", "[web-2/app] fake logs"}, lines)
+	})
+
+	t.Run("Success - Follow pods joining and leaving", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(
+			runningLogPod("web-1", web, "app"),
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+				Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+			},
+		)
+		watcher := watch.NewFake()
+		clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			return true, watcher, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+		monkey.Patch(streamPodLogs, func(ctx context.Context, cs kubernetes.Interface, namespace, pod string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+			return openLogStream(ctx, "fake logs"), nil
+		})
+		defer monkey.Unpatch(streamPodLogs)
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?namespace=ns1&clusterId=c1&workload=deployment/web", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.JSONEq(t, `{"type":"logSource","event":"added","pod":"web-1","container":"app"}`, readMessage(t, conn))
+		assert.Equal(t, "[web-1/app] fake logs", readMessage(t, conn))
+
+		// 셀렉터에 맞지 않는 파드는 무시
+		watcher.Add(runningLogPod("db-1", map[string]string{"app": "db"}, "db"))
+		watcher.Add(runningLogPod("web-2", web, "app"))
+		assert.JSONEq(t, `{"type":"logSource","event":"added","pod":"web-2","container":"app"}`, readMessage(t, conn))
+		assert.Equal(t, "[web-2/app] fake logs", readMessage(t, conn))
+
+		// 이미 따라가는 컨테이너는 다시 시작하지 않음
+		watcher.Modify(runningLogPod("web-2", web, "app"))
+		watcher.Delete(runningLogPod("web-1", web, "app"))
+		assert.JSONEq(t, `{"type":"logSource","event":"removed","pod":"web-1","container":"app"}`, readMessage(t, conn))
+	})
+
+	t.Run("Failure - Stream error is retried on next pod update", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(runningLogPod("web-1", web, "app"))
+		watcher := watch.NewFake()
+		clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			return true, watcher, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+		var mu sync.Mutex
+		var attempts []*corev1.PodLogOptions
+		monkey.Patch(streamPodLogs, func(ctx context.Context, cs kubernetes.Interface, namespace, pod string, options *corev1.PodLogOptions) (io.ReadCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts = append(attempts, options.DeepCopy())
+			if len(attempts) == 1 {
+				return io.NopCloser(strings.NewReader("2025-01-01T09:00:00Z first\n")), nil
+			}
+			if len(attempts) == 2 {
+				return nil, errors.New("container is not ready")
+			}
+			return openLogStream(ctx, "2025-01-01T09:00:01Z second"), nil
+		})
+		defer monkey.Unpatch(streamPodLogs)
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?namespace=ns1&clusterId=c1&selector=app%3Dweb", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.JSONEq(t, `{"type":"logSource","event":"added","pod":"web-1","container":"app"}`, readMessage(t, conn))
+		assert.Equal(t, "[web-1/app] 2025-01-01T09:00:00Z first", readMessage(t, conn))
+
+		// 스트림이 끝나면 다음 변경 때 마지막 줄 이후부터 다시 따라감. 실패는 클라이언트에 알림
+		watcher.Modify(runningLogPod("web-1", web, "app"))
+		assert.JSONEq(t, `{"type":"logSource","event":"added","pod":"web-1","container":"app"}`, readMessage(t, conn))
+		assert.Contains(t, readMessage(t, conn), "Log stream for web-1/app failed: container is not ready")
+
+		watcher.Modify(runningLogPod("web-1", web, "app"))
+		assert.JSONEq(t, `{"type":"logSource","event":"added","pod":"web-1","container":"app"}`, readMessage(t, conn))
+		assert.Equal(t, "[web-1/app] 2025-01-01T09:00:01Z second", readMessage(t, conn))
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, attempts, 3)
+		assert.Nil(t, attempts[0].SinceTime)
+		for _, options := range attempts[1:] {
+			require.NotNil(t, options.SinceTime)
+			assert.True(t, options.SinceTime.Equal(&metav1.Time{Time: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)}))
+		}
+	})
+
+	t.Run("Failure - Workload not found", func(t *testing.T) {
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?namespace=ns1&clusterId=c1&workload=deployment/missing", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.Contains(t, readMessage(t, conn), `"reason":"PodNotFound"`)
+	})
+
+	t.Run("Failure - Invalid selector", func(t *testing.T) {
+		for _, query := range []string{"selector=app+in+(web", "workload=cronjob/web", "selector=app%3Dweb&workload=deployment/web"} {
+			_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?namespace=ns1&"+query, nil)
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/session"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const sessionKindNode = "node"
+
+const (
+	controlTypeNode     = "node"
+	nodePhaseScheduling = "scheduling"
+	nodePhaseRunning    = "running"
+
+	defaultNodeDebugNamespace = "default"
+	defaultNodeDebugImage     = "busybox:1.36"
+	// 세션 최대 시간이 없을 때 파드 자체 만료 시간 (서비스 장애로 삭제되지 못한 경우 대비)
+	defaultNodeDebugDeadline = 8 * time.Hour
+	nodeDebugContainer       = "debugger"
+	nodeDebugHostMount       = "/host"
+	nodeDebugDeleteTimeout   = 30 * time.Second
+
+	nodeDebugLabel = "cp-remote-api/node-debug"
+
+	auditEventNodeDebugPod        = "node.debugPod"
+	auditEventNodeDebugPodCleanup = "node.debugPodCleanup"
+)
+
+// 호스트 루트로 chroot 후 로그인 셸 실행 (bash 가 없으면 sh)
+var nodeShellCommand = []string{"chroot", nodeDebugHostMount, "/bin/sh", "-c",
+	"if [ -x /bin/bash ]; then exec /bin/bash -l; else exec /bin/sh -l; fi"}
+
+// 노드 디버그 파드 준비 진행 상황
+type nodeMessage struct {
+	Type  string `json:"type"`
+	Phase string `json:"phase"`
+	Node  string `json:"node"`
+	Pod   string `json:"pod"`
+}
+
+func nodeDebugNamespace() string {
+	if config.Env != nil && config.Env.NodeDebugNamespace != "" {
+		return config.Env.NodeDebugNamespace
+	}
+	return defaultNodeDebugNamespace
+}
+
+func nodeDebugImage() string {
+	if config.Env != nil && config.Env.NodeDebugImage != "" {
+		return config.Env.NodeDebugImage
+	}
+	return defaultNodeDebugImage
+}
+
+// hostPID/hostNetwork 를 사용하고 호스트 루트를 마운트한 특권 파드
+func nodeDebugPod(node, namespace, user string, deadline time.Duration) *corev1.Pod {
+	privileged := true
+	deadlineSeconds := int64(deadline.Seconds())
+	gracePeriod := int64(0)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node-debugger-" + utilrand.String(5),
+			Namespace:   namespace,
+			Labels:      map[string]string{nodeDebugLabel: "true", "app.kubernetes.io/managed-by": "cp-remote-api"},
+			Annotations: map[string]string{"cp-remote-api/user": user, "cp-remote-api/node": node},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:                      node,
+			HostPID:                       true,
+			HostNetwork:                   true,
+			HostIPC:                       true,
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadlineSeconds,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            nodeDebugContainer,
+				Image:           nodeDebugImage(),
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				// 주 프로세스가 stdin 을 기다리며 유지됨
+				Stdin:           true,
+				TTY:             true,
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "host-root", MountPath: nodeDebugHostMount}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "host-root",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+			}},
+		},
+	}
+}
+
+// 파드가 Running 이 될 때까지 대기. 이미지 풀 실패나 종료 시 즉시 실패
+func waitForPodRunning(ctx context.Context, clientset kubernetes.Interface, namespace, pod string) error {
+	var lastReason string
+	err := wait.PollUntilContextTimeout(ctx, debugPollInterval, debugStartTimeout(), true, func(ctx context.Context) (bool, error) {
+		podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch podInfo.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodFailed, corev1.PodSucceeded:
+			return false, fmt.Errorf("node debug pod is not running: %s %s", podInfo.Status.Reason, podInfo.Status.Message)
+		}
+		for _, status := range podInfo.Status.ContainerStatuses {
+			if status.State.Waiting != nil {
+				lastReason = status.State.Waiting.Reason
+				if debugStartFailureReasons[lastReason] {
+					return false, fmt.Errorf("node debug pod is not running: %s %s", lastReason, status.State.Waiting.Message)
+				}
+			}
+		}
+		return false, nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out waiting for node debug pod to start (last state %q): %w", lastReason, err)
+	}
+	return err
+}
+
+func NodeExecWebSocketHandler(c *gin.Context) {
+	var node = c.Query("node")
+	var clusterId = c.Query("clusterId")
+	namespace := nodeDebugNamespace()
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+	if node == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "node is required"})
+		return
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Failed to create clientset"))
+		return
+	}
+
+	wsStream := newWebSocketStream(conn)
+	initialSize, hasInitialSize := terminalSizeFromQuery(c)
+	if hasInitialSize {
+		wsStream.resize(initialSize)
+	}
+
+	ctx := c.Request.Context()
+	if _, err := clientset.CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{}); err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, "", "", time.Now(), err))
+		return
+	}
+
+	target := newTerminalTarget(c, claims, sessionKindNode)
+	target.Node = node
+	target.Namespace = namespace
+	target.Container = nodeDebugContainer
+
+	deadline := defaultNodeDebugDeadline
+	if limits := session.LimitsFromEnv(clusterId, target.UserType); limits.MaxDuration > 0 {
+		deadline = limits.MaxDuration
+	}
+	pod, err := clientset.CoreV1().Pods(namespace).Create(ctx, nodeDebugPod(node, namespace, target.User, deadline), metav1.CreateOptions{})
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, "", "", time.Now(), err))
+		return
+	}
+	target.Pod = pod.Name
+	nodeAudit := audit.Event{
+		Type:      auditEventNodeDebugPod,
+		Kind:      sessionKindNode,
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Node:      node,
+		Namespace: namespace,
+		Pod:       pod.Name,
+		ClientIP:  target.ClientIP,
+	}
+	audit.Log(nodeAudit)
+	defer deleteNodeDebugPod(clientset, nodeAudit)
+
+	wsStream.writeJSON(nodeMessage{Type: controlTypeNode, Phase: nodePhaseScheduling, Node: node, Pod: pod.Name})
+	if err := waitForPodRunning(ctx, clientset, namespace, pod.Name); err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod.Name, nodeDebugContainer, time.Now(), err))
+		return
+	}
+	wsStream.writeJSON(nodeMessage{Type: controlTypeNode, Phase: nodePhaseRunning, Node: node, Pod: pod.Name})
+
+	executor, err := newExecutor(clientset, cfg, pod.Name, namespace, &corev1.PodExecOptions{
+		Container: nodeDebugContainer,
+		Command:   nodeShellCommand,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       true,
+	})
+	if err != nil {
+		wsStream.writeText("Executor error:" + err.Error())
+		wsStream.close(websocket.CloseInternalServerErr, "executor error")
+		return
+	}
+	runTerminalSession(ctx, wsStream, clientset, target, initialSize, executor, terminalMode{Stdin: true, Tty: true})
+}
+
+// 세션 종료 방식과 무관하게 디버그 파드 삭제. 요청 컨텍스트가 취소된 뒤에도 실행되도록 별도 컨텍스트 사용
+func deleteNodeDebugPod(clientset kubernetes.Interface, event audit.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeDebugDeleteTimeout)
+	defer cancel()
+
+	gracePeriod := int64(0)
+	err := clientset.CoreV1().Pods(event.Namespace).Delete(ctx, event.Pod, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	event.Type = auditEventNodeDebugPodCleanup
+	event.Time = time.Time{}
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Printf("노드 디버그 파드 삭제 실패 (%s/%s): %v", event.Namespace, event.Pod, err)
+		event.Reason = "DeleteFailed"
+		event.Message = err.Error()
+	}
+	audit.Log(event)
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// --- [ NodeExecWebSocketHandler 테스트 ] ---
+
+func TestNodeDebugPod(t *testing.T) {
+	config.Env = &config.EnvConfigs{NodeDebugImage: "registry.local/toolbox:1"}
+	pod := nodeDebugPod("worker-1", "ops", "admin-1", time.Hour)
+
+	assert.True(t, strings.HasPrefix(pod.Name, "node-debugger-"))
+	assert.Equal(t, "ops", pod.Namespace)
+	assert.Equal(t, "worker-1", pod.Spec.NodeName)
+	assert.True(t, pod.Spec.HostPID)
+	assert.True(t, pod.Spec.HostNetwork)
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	assert.Equal(t, int64(3600), *pod.Spec.ActiveDeadlineSeconds)
+	require.Len(t, pod.Spec.Containers, 1)
+	assert.Equal(t, "registry.local/toolbox:1", pod.Spec.Containers[0].Image)
+	assert.True(t, *pod.Spec.Containers[0].SecurityContext.Privileged)
+	assert.Equal(t, "/host", pod.Spec.Containers[0].VolumeMounts[0].MountPath)
+	assert.Equal(t, "/", pod.Spec.Volumes[0].HostPath.Path)
+}
+
+// newNodeClientset: 생성된 파드를 지정한 상태로 보고하는 fake clientset
+func newNodeClientset(status corev1.PodStatus) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}})
+	clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, err := clientset.Tracker().Get(action.GetResource(), action.GetNamespace(), action.(k8stesting.GetAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		pod := obj.(*corev1.Pod).DeepCopy()
+		pod.Status = status
+		return true, pod, nil
+	})
+	return clientset
+}
+
+func TestNodeExecWebSocketHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{NodeDebugNamespace: "ops"}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(audit.Log, func(event audit.Event) {})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		assert.Equal(t, "ops", ns)
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "admin-1", "userType": "SUPER_ADMIN"})
+		c.Next()
+	})
+	r.GET("/ws/node-exec", NodeExecWebSocketHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "http", "ws", 1) + "/ws/node-exec"
+
+	assertPodDeleted := func(t *testing.T, clientset *fake.Clientset) {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			pods, err := clientset.Tracker().List(corev1.SchemeGroupVersion.WithResource("pods"), corev1.SchemeGroupVersion.WithKind("Pod"), "ops")
+			require.NoError(t, err)
+			if len(pods.(*corev1.PodList).Items) == 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("노드 디버그 파드가 삭제되지 않음")
+	}
+
+	t.Run("Success - Host shell and cleanup", func(t *testing.T) {
+		clientset := newNodeClientset(corev1.PodStatus{Phase: corev1.PodRunning})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			assert.Equal(t, "ops", n)
+			assert.Equal(t, nodeDebugContainer, opts.Container)
+			assert.Equal(t, []string{"chroot", "/host"}, opts.Command[:2])
+			return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+				options.Stdout.Write([]byte("root@worker-1:/# "))
+				return nil
+			}}, nil
+		})
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?clusterId=c1&node=worker-1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var msg nodeMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, "scheduling", msg.Phase)
+		assert.Equal(t, "worker-1", msg.Node)
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, "running", msg.Phase)
+
+		_, out, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "root@worker-1:/# ", string(out))
+		_, out, err = conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"reason":"Completed"`)
+
+		assertPodDeleted(t, clientset)
+	})
+
+	t.Run("Failure - Pod cannot start is still deleted", func(t *testing.T) {
+		clientset := newNodeClientset(corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  nodeDebugContainer,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		}}})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: clientset}
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?clusterId=c1&node=worker-1", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var exit execExit
+		for exit.Type != controlTypeExit {
+			require.NoError(t, conn.ReadJSON(&exit))
+		}
+		assert.Equal(t, exitReasonContainerNotRunning, exit.Reason)
+		assert.Contains(t, exit.Message, "ImagePullBackOff")
+
+		assertPodDeleted(t, clientset)
+	})
+
+	t.Run("Failure - Unknown node", func(t *testing.T) {
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: newNodeClientset(corev1.PodStatus{})}
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?clusterId=c1&node=worker-9", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var exit execExit
+		require.NoError(t, conn.ReadJSON(&exit))
+		assert.Equal(t, exitReasonPodNotFound, exit.Reason)
+		assert.Contains(t, exit.Message, "worker-9")
+	})
+}
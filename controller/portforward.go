@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/session"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const sessionKindPortForward = "portforward"
+
+// error 스트림이 먼저 닫혔을 때 남은 응답 중계를 기다리는 최대 시간
+var portForwardDrainTimeout = 5 * time.Second
+
+const (
+	controlTypePortForward = "portforward"
+	portForwardReady       = "ready"
+	portForwardNotice      = "notice"
+)
+
+// 포트 포워딩 준비 완료 및 서버 안내. 데이터는 바이너리 프레임으로만 전달
+type portForwardMessage struct {
+	Type    string `json:"type"`
+	Event   string `json:"event"`
+	Port    int    `json:"port"`
+	Message string `json:"message,omitempty"`
+}
+
+// websocket 터널을 우선 사용하고, API 서버가 지원하지 않으면 SPDY 로 연결
+var newPortForwardDialer = func(clientset kubernetes.Interface, cfg *rest.Config, pod, namespace string) (httpstream.Dialer, error) {
+	req := clientset.CoreV1().RESTClient().
+		Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+	tunnelingDialer, err := portforward.NewSPDYOverWebsocketDialer(req.URL(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return portforward.NewFallbackDialer(tunnelingDialer, spdyDialer, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	}), nil
+}
+
+func portFromQuery(c *gin.Context) (int, error) {
+	port, err := strconv.Atoi(c.Query("port"))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port: %q", c.Query("port"))
+	}
+	return port, nil
+}
+
+// kubectl port-forward 와 같은 방식으로 error/data 스트림 쌍 생성
+func createPortForwardStreams(conn httpstream.Connection, port int) (httpstream.Stream, httpstream.Stream, error) {
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating error stream for port %d: %w", port, err)
+	}
+	// error 스트림은 읽기만 함
+	errorStream.Close()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating forwarding stream for port %d: %w", port, err)
+	}
+	return errorStream, dataStream, nil
+}
+
+func PortForwardWebSocketHandler(c *gin.Context) {
+	var pod = c.Query("pod")
+	var namespace = c.Query("namespace")
+	var clusterId = c.Query("clusterId")
+
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+
+	port, err := portFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, claims["userAuthId"].(string), claims["userType"].(string), namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	clientset, err := K8sClientFactoryImpl.NewForConfig(cfg)
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Failed to create clientset"))
+		return
+	}
+
+	wsStream := newRawStream(conn)
+	startedAt := time.Now()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	podInfo, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, "", startedAt, err))
+		return
+	}
+	if podInfo.Status.Phase != corev1.PodRunning {
+		wsStream.writeExit(execExit{Type: controlTypeExit, Reason: exitReasonContainerNotRunning,
+			Message: fmt.Sprintf("pod %s is not running (phase %s)", pod, podInfo.Status.Phase)})
+		return
+	}
+
+	dialer, err := newPortForwardDialer(clientset, cfg, pod, namespace)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, "", startedAt, err))
+		return
+	}
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, "", startedAt, err))
+		return
+	}
+	defer streamConn.Close()
+
+	errorStream, dataStream, err := createPortForwardStreams(streamConn, port)
+	if err != nil {
+		wsStream.writeExit(describeExecExit(nil, namespace, pod, "", startedAt, err))
+		return
+	}
+	defer streamConn.RemoveStreams(errorStream, dataStream)
+
+	target := newTerminalTarget(c, claims, sessionKindPortForward)
+	ctx, cancel = context.WithCancel(c.Request.Context())
+	defer cancel()
+	sess := session.New(session.Info{
+		ID:        newSessionID(),
+		Kind:      target.Kind,
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Namespace: target.Namespace,
+		Pod:       target.Pod,
+		ClientIP:  target.ClientIP,
+	}, func(session.Termination) { cancel() })
+	sess.OnNotice(func(text string) {
+		wsStream.writeJSON(portForwardMessage{Type: controlTypePortForward, Event: portForwardNotice, Port: port, Message: text})
+	})
+	wsStream.addObserver(sess)
+	SessionRegistry.Add(sess)
+	defer SessionRegistry.Remove(sess.ID())
+	defer sess.Close()
+
+	startEvent := sessionAuditEvent(audit.EventSessionStart, sess.Info())
+	startEvent.Details = map[string]interface{}{"port": port}
+	audit.Log(startEvent)
+
+	go sess.Enforce(ctx, session.LimitsFromEnv(target.Cluster, target.UserType), func(warning session.Warning) {
+		wsStream.writeJSON(portForwardMessage{Type: controlTypePortForward, Event: portForwardNotice, Port: port, Message: warning.Message})
+	})
+	wsStream.writeJSON(portForwardMessage{Type: controlTypePortForward, Event: portForwardReady, Port: port})
+
+	// 컨테이너가 보낸 포워딩 오류 (예: 포트에서 수신 대기 중인 프로세스 없음)
+	remoteErr := make(chan error, 1)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			remoteErr <- fmt.Errorf("error reading from error stream for port %d: %w", port, err)
+		case len(message) > 0:
+			remoteErr <- fmt.Errorf("an error occurred forwarding port %d: %s", port, message)
+		default:
+			remoteErr <- nil
+		}
+	}()
+
+	// 클라이언트 → 컨테이너. 클라이언트가 빈 바이너리 프레임으로 쓰기를 끝내면 쓰기 방향만 닫고,
+	// 컨테이너의 응답은 copied 가 끝날 때까지 계속 중계
+	go func() {
+		io.Copy(dataStream, wsStream)
+		dataStream.Close()
+	}()
+
+	// 컨테이너 → 클라이언트
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(wsStream, dataStream)
+		copied <- err
+	}()
+
+	var exit execExit
+	select {
+	case err = <-copied:
+		exit = execExit{Type: controlTypeExit, Reason: exitReasonCompleted, Message: "connection closed by container"}
+		if err != nil {
+			exit = execExit{Type: controlTypeExit, Reason: exitReasonError, Message: err.Error()}
+		}
+		// 연결 종료와 함께 온 오류 메시지 확인
+		select {
+		case err := <-remoteErr:
+			if err != nil {
+				exit = execExit{Type: controlTypeExit, Reason: exitReasonError, Message: err.Error()}
+			}
+		case <-time.After(100 * time.Millisecond):
+		}
+	case err = <-remoteErr:
+		exit = execExit{Type: controlTypeExit, Reason: exitReasonCompleted, Message: "connection closed by container"}
+		if err == nil {
+			// 아직 전달 중인 컨테이너 응답이 잘리지 않도록 중계가 끝나기를 잠시 기다림
+			select {
+			case err = <-copied:
+			case <-wsStream.done:
+			case <-ctx.Done():
+			case <-time.After(portForwardDrainTimeout):
+			}
+		}
+		if err != nil {
+			exit = execExit{Type: controlTypeExit, Reason: exitReasonError, Message: err.Error()}
+		}
+	case <-wsStream.done:
+		sess.Terminate(session.Termination{Reason: exitReasonDisconnected, Message: "client connection lost"})
+	case <-ctx.Done():
+	}
+	if termination, ok := sess.Termination(); ok {
+		exit = execExit{Type: controlTypeExit, Reason: termination.Reason, Message: termination.Message}
+	}
+
+	endEvent := sessionAuditEvent(audit.EventSessionEnd, sess.Info())
+	endEvent.Reason = exit.Reason
+	endEvent.Message = exit.Message
+	audit.Log(endEvent)
+	wsStream.writeExit(exit)
+}
@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/model"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// --- [ PortForwardWebSocketHandler 테스트 ] ---
+
+type fakePortForwardStream struct {
+	io.ReadWriteCloser
+	headers http.Header
+}
+
+func (s *fakePortForwardStream) Reset() error         { return s.Close() }
+func (s *fakePortForwardStream) Headers() http.Header { return s.headers }
+func (s *fakePortForwardStream) Identifier() uint32   { return 0 }
+
+// 컨테이너 쪽 포트를 net.Pipe 로 대신하는 연결
+type fakePortForwardConnection struct {
+	mu       sync.Mutex
+	headers  []http.Header
+	remote   io.ReadWriteCloser
+	errorMsg string
+	// 지정하면 연결 종료 대신 이 채널이 닫힐 때 error 스트림이 EOF
+	errorDone chan bool
+	closed    chan bool
+}
+
+func newFakePortForwardConnection() *fakePortForwardConnection {
+	return &fakePortForwardConnection{closed: make(chan bool)}
+}
+
+func (c *fakePortForwardConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = append(c.headers, headers.Clone())
+	if headers.Get(corev1.StreamType) == corev1.StreamTypeError {
+		body := &errorStreamBody{Reader: strings.NewReader(c.errorMsg)}
+		if c.errorMsg == "" {
+			body.wait = c.closed
+			if c.errorDone != nil {
+				body.wait = c.errorDone
+			}
+		}
+		return &fakePortForwardStream{ReadWriteCloser: body, headers: headers}, nil
+	}
+	local, remote := newHalfClosePipe()
+	c.remote = remote
+	return &fakePortForwardStream{ReadWriteCloser: local, headers: headers}, nil
+}
+
+// SPDY 데이터 스트림처럼 Close 는 쓰기 방향만 닫음
+type halfCloseConn struct {
+	io.Reader
+	io.Writer
+	close func() error
+}
+
+func (c *halfCloseConn) Close() error { return c.close() }
+
+// local 은 중계 서버 쪽, remote 는 컨테이너 쪽. remote 를 닫으면 양방향 모두 끊김
+func newHalfClosePipe() (*halfCloseConn, *halfCloseConn) {
+	upReader, upWriter := io.Pipe()
+	downReader, downWriter := io.Pipe()
+	local := &halfCloseConn{Reader: downReader, Writer: upWriter, close: upWriter.Close}
+	remote := &halfCloseConn{Reader: upReader, Writer: downWriter, close: func() error {
+		upReader.Close()
+		return downWriter.Close()
+	}}
+	return local, remote
+}
+
+func (c *fakePortForwardConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	if c.remote != nil {
+		c.remote.Close()
+	}
+	return nil
+}
+
+func (c *fakePortForwardConnection) CloseChan() <-chan bool                     { return c.closed }
+func (c *fakePortForwardConnection) SetIdleTimeout(timeout time.Duration)       {}
+func (c *fakePortForwardConnection) RemoveStreams(streams ...httpstream.Stream) {}
+
+// 오류가 없으면 연결이 닫힐 때까지 EOF 를 미룸
+type errorStreamBody struct {
+	io.Reader
+	wait chan bool
+}
+
+func (b *errorStreamBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF && b.wait != nil {
+		<-b.wait
+	}
+	return n, err
+}
+func (b *errorStreamBody) Write(p []byte) (int, error) { return len(p), nil }
+func (b *errorStreamBody) Close() error                { return nil }
+
+type fakePortForwardDialer struct {
+	conn *fakePortForwardConnection
+}
+
+func (d *fakePortForwardDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	return d.conn, protocols[0], nil
+}
+
+func TestPortForwardWebSocketHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	var auditMu sync.Mutex
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		events = append(events, event)
+	})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "jvm", Namespace: "ns1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "ns1"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	)}
+
+	var conn *fakePortForwardConnection
+	monkey.Patch(newPortForwardDialer, func(cs kubernetes.Interface, cfg *rest.Config, pod, namespace string) (httpstream.Dialer, error) {
+		return &fakePortForwardDialer{conn: conn}, nil
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER"})
+		c.Next()
+	})
+	r.GET("/ws/portforward", PortForwardWebSocketHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	wsURL := strings.Replace(server.URL, "http", "ws", 1) + "/ws/portforward"
+
+	t.Run("Success - Relay binary frames", func(t *testing.T) {
+		events = nil
+		conn = newFakePortForwardConnection()
+		client, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=jvm&namespace=ns1&clusterId=c1&port=5005", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		msgType, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.JSONEq(t, `{"type":"portforward","event":"ready","port":5005}`, string(msg))
+
+		conn.mu.Lock()
+		require.Len(t, conn.headers, 2)
+		assert.Equal(t, corev1.StreamTypeError, conn.headers[0].Get(corev1.StreamType))
+		assert.Equal(t, corev1.StreamTypeData, conn.headers[1].Get(corev1.StreamType))
+		assert.Equal(t, "5005", conn.headers[1].Get(corev1.PortHeader))
+		remote := conn.remote
+		conn.mu.Unlock()
+
+		// 한 프레임이 Read 버퍼보다 커도 모두 전달
+		payload := []byte(strings.Repeat("x", 40*1024))
+		require.NoError(t, client.WriteMessage(websocket.BinaryMessage, payload))
+		received := make([]byte, len(payload))
+		_, err = io.ReadFull(remote, received)
+		require.NoError(t, err)
+		assert.Equal(t, payload, received)
+
+		_, err = remote.Write([]byte("JDWP-Handshake"))
+		require.NoError(t, err)
+		msgType, msg, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, msgType)
+		assert.Equal(t, "JDWP-Handshake", string(msg))
+
+		// 컨테이너 쪽 연결 종료
+		remote.Close()
+		_, msg, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Completed"`)
+		_, _, err = client.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+		waitForSessions(t, 0)
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		require.Len(t, events, 2)
+		assert.Equal(t, audit.EventSessionStart, events[0].Type)
+		assert.Equal(t, sessionKindPortForward, events[0].Kind)
+		assert.Equal(t, 5005, events[0].Details["port"])
+		assert.Equal(t, audit.EventSessionEnd, events[1].Type)
+		assert.Equal(t, exitReasonCompleted, events[1].Reason)
+	})
+
+	t.Run("Success - Response after client half-close", func(t *testing.T) {
+		conn = newFakePortForwardConnection()
+		client, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=jvm&namespace=ns1&clusterId=c1&port=8080", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"event":"ready"`)
+		conn.mu.Lock()
+		remote := conn.remote
+		conn.mu.Unlock()
+
+		// 요청 후 빈 바이너리 프레임으로 쓰기 종료. 컨테이너는 EOF 를 받은 뒤 응답
+		require.NoError(t, client.WriteMessage(websocket.BinaryMessage, []byte("QUIT")))
+		require.NoError(t, client.WriteMessage(websocket.BinaryMessage, []byte{}))
+		request, err := io.ReadAll(remote)
+		require.NoError(t, err)
+		assert.Equal(t, "QUIT", string(request))
+
+		go func() {
+			remote.Write([]byte("BYE"))
+			remote.Close()
+		}()
+		msgType, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, msgType)
+		assert.Equal(t, "BYE", string(msg))
+		_, msg, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Completed"`)
+		waitForSessions(t, 0)
+	})
+
+	t.Run("Success - Response in flight when error stream closes", func(t *testing.T) {
+		conn = newFakePortForwardConnection()
+		conn.errorDone = make(chan bool)
+		client, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=jvm&namespace=ns1&clusterId=c1&port=8080", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"event":"ready"`)
+		conn.mu.Lock()
+		remote := conn.remote
+		conn.mu.Unlock()
+
+		// error 스트림이 먼저 정상 종료된 뒤 남은 응답이 도착
+		close(conn.errorDone)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			remote.Write([]byte("LAST"))
+			remote.Close()
+		}()
+		msgType, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, msgType)
+		assert.Equal(t, "LAST", string(msg))
+		_, msg, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Completed"`)
+		waitForSessions(t, 0)
+	})
+
+	t.Run("Failure - Nothing listening on port", func(t *testing.T) {
+		conn = newFakePortForwardConnection()
+		conn.errorMsg = "failed to connect to localhost:5005 inside namespace: connection refused"
+		client, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=jvm&namespace=ns1&clusterId=c1&port=5005", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"event":"ready"`)
+
+		_, msg, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"Error"`)
+		assert.Contains(t, string(msg), "connection refused")
+	})
+
+	t.Run("Failure - Pod not running", func(t *testing.T) {
+		client, _, err := websocket.DefaultDialer.Dial(wsURL+"?pod=pending&namespace=ns1&clusterId=c1&port=8080", nil)
+		require.NoError(t, err)
+		defer client.Close()
+
+		_, msg, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(msg), `"reason":"ContainerNotRunning"`)
+	})
+
+	t.Run("Failure - Invalid port", func(t *testing.T) {
+		for _, port := range []string{"", "0", "70000", "http"} {
+			_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?pod=jvm&namespace=ns1&port="+port, nil)
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, port)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultProxyTokenTTL = 15 * time.Minute
+	proxyTokenAudience   = "proxy"
+)
+
+// [scheme:]name[:port] (pods/proxy, services/proxy 의 대상 형식)
+var proxyTargetPattern = regexp.MustCompile(`^(https?:)?[a-z0-9]([-a-z0-9.]*[a-z0-9])?(:[a-zA-Z0-9-]+)?$`)
+
+// DNS-1123 label (네임스페이스 이름 형식)
+var proxyNamespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+var proxyKinds = map[string]bool{"pods": true, "services": true}
+
+func validateProxyTarget(namespace, kind, target string) error {
+	if !proxyNamespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace: %s", namespace)
+	}
+	if !proxyKinds[kind] {
+		return errors.New("kind must be pods or services")
+	}
+	if !proxyTargetPattern.MatchString(target) {
+		return fmt.Errorf("invalid proxy target: %s", target)
+	}
+	return nil
+}
+
+func proxyTokenTTL() time.Duration {
+	if config.Env != nil && config.Env.ProxyTokenTTLSeconds > 0 {
+		return time.Duration(config.Env.ProxyTokenTTLSeconds) * time.Second
+	}
+	return defaultProxyTokenTTL
+}
+
+// 프록시 토큰 서명 키. JWT_SECRET 에서 파생하므로 일반 API 의 JWT 로는 사용할 수 없음
+func proxyTokenKey() []byte {
+	mac := hmac.New(sha512.New, []byte(config.Env.JwtSecret))
+	mac.Write([]byte("cp-remote-api proxy token"))
+	return mac.Sum(nil)
+}
+
+// 브라우저에서 여는 프록시 경로에 포함되는 토큰. 발급한 사용자와 대상 하나에만 유효
+type proxyTokenClaims struct {
+	UserAuthId string `json:"userAuthId"`
+	UserType   string `json:"userType"`
+	ClusterId  string `json:"clusterId"`
+	Namespace  string `json:"namespace"`
+	Kind       string `json:"kind"`
+	Target     string `json:"target"`
+	jwt.RegisteredClaims
+}
+
+func parseProxyToken(token string) (*proxyTokenClaims, error) {
+	claims := &proxyTokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return proxyTokenKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}), jwt.WithAudience(proxyTokenAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateProxyTarget(claims.Namespace, claims.Kind, claims.Target); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+type proxyTokenRequest struct {
+	ClusterId string `json:"clusterId" binding:"required"`
+	Namespace string `json:"namespace" binding:"required"`
+	Kind      string `json:"kind" binding:"required"`
+	Target    string `json:"target" binding:"required"`
+}
+
+type proxyTokenResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// API 서버의 proxy 하위 리소스 경로. 예: /api/v1/namespaces/ns1/pods/web:8080/proxy
+func apiProxyPath(namespace, kind, target string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/%s/%s/proxy", namespace, kind, target)
+}
+
+// 요청 경로 정규화. 상위 경로(..)로 proxy 하위 리소스 밖을 가리킬 수 없도록 함
+func cleanProxyPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// 응답의 Location 을 이 서비스의 proxy 경로 기준으로 변환
+func rewriteProxyLocation(location string, apiServer *url.URL, apiPrefix, publicPrefix string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	if u.IsAbs() {
+		// 외부 주소로의 리다이렉트는 그대로 둠
+		if u.Host != apiServer.Host {
+			return location
+		}
+		u.Scheme, u.Host = "", ""
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		return u.String()
+	}
+	switch {
+	case u.Path == apiPrefix || strings.HasPrefix(u.Path, apiPrefix+"/"):
+		u.Path = publicPrefix + strings.TrimPrefix(u.Path, apiPrefix)
+	case u.Path == publicPrefix || strings.HasPrefix(u.Path, publicPrefix+"/"):
+	default:
+		// 앱 루트 기준 절대 경로
+		u.Path = publicPrefix + u.Path
+	}
+	u.RawPath = ""
+	return u.String()
+}
+
+// 이 서비스의 인증 정보(JWT)가 대상 앱으로 전달되지 않도록 제거
+func stripProxyCredentials(header http.Header) {
+	header.Del("Authorization")
+	protocols := header.Values("Sec-WebSocket-Protocol")
+	if len(protocols) == 0 {
+		return
+	}
+	var kept []string
+	var parts []string
+	for _, value := range protocols {
+		parts = append(parts, strings.Split(value, ",")...)
+	}
+	for i := 0; i < len(parts); i++ {
+		part := strings.TrimSpace(parts[i])
+		if part == bearerProtocol {
+			i++
+			continue
+		}
+		kept = append(kept, part)
+	}
+	header.Del("Sec-WebSocket-Protocol")
+	if len(kept) > 0 {
+		header.Set("Sec-WebSocket-Protocol", strings.Join(kept, ", "))
+	}
+}
+
+// 브라우저용 프록시 URL 발급. 페이지 이동과 하위 리소스 요청은 Authorization 헤더를 보낼 수 없으므로
+// 서명된 토큰을 경로에 포함해 상대 경로 링크도 같은 토큰으로 인증되도록 함
+func CreateProxyTokenHandler(c *gin.Context) {
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+
+	var req proxyTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := validateProxyTarget(req.Namespace, req.Kind, req.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 호출자의 JWT 보다 오래 유효하지 않음
+	expiresAt := time.Now().Add(proxyTokenTTL()).Truncate(time.Second)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil && exp.Time.Before(expiresAt) {
+		expiresAt = exp.Time
+	}
+	userAuthId, _ := claims["userAuthId"].(string)
+	userType, _ := claims["userType"].(string)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, proxyTokenClaims{
+		UserAuthId: userAuthId,
+		UserType:   userType,
+		ClusterId:  req.ClusterId,
+		Namespace:  req.Namespace,
+		Kind:       req.Kind,
+		Target:     req.Target,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{proxyTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}).SignedString(proxyTokenKey())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign proxy token: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, proxyTokenResponse{URL: "/proxy-token/" + token + "/", ExpiresAt: expiresAt})
+}
+
+// /proxy/:clusterId/:namespace/:kind/:target/*path 를 pods/proxy 또는 services/proxy 로 전달 (websocket 업그레이드 포함)
+func ProxyHandler(c *gin.Context) {
+	val, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "claims not found"})
+		return
+	}
+	claims := val.(jwt.MapClaims)
+	serveProxy(c, claims["userAuthId"].(string), claims["userType"].(string),
+		c.Param("clusterId"), c.Param("namespace"), c.Param("kind"), c.Param("target"))
+}
+
+// /proxy-token/:token/*path. AuthMiddleware 없이 경로의 프록시 토큰으로 인증
+func ProxyTokenHandler(c *gin.Context) {
+	claims, err := parseProxyToken(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, "TOKEN_EXPIRED")
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, "TOKEN_FAILED")
+		return
+	}
+	serveProxy(c, claims.UserAuthId, claims.UserType, claims.ClusterId, claims.Namespace, claims.Kind, claims.Target)
+}
+
+func serveProxy(c *gin.Context, userAuthId, userType, clusterId, namespace, kind, target string) {
+	if err := validateProxyTarget(namespace, kind, target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterInfo, err := GetClusterInfo(clusterId, userAuthId, userType, namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster info: " + err.Error()})
+		return
+	}
+	cfg := &rest.Config{
+		Host:        clusterInfo.APIServerURL,
+		BearerToken: clusterInfo.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: true,
+		},
+	}
+	apiServer, err := url.Parse(clusterInfo.APIServerURL)
+	if err != nil || apiServer.Host == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid API server URL"})
+		return
+	}
+	transport, err := rest.TransportFor(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create transport: " + err.Error()})
+		return
+	}
+
+	apiPrefix := strings.TrimSuffix(apiServer.Path, "/") + apiProxyPath(namespace, kind, target)
+	publicPrefix := strings.TrimSuffix(c.Request.URL.Path, c.Param("path"))
+	upstreamPath := apiPrefix + cleanProxyPath(c.Param("path"))
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = apiServer.Scheme
+			r.Out.URL.Host = apiServer.Host
+			r.Out.URL.Path = upstreamPath
+			r.Out.URL.RawPath = ""
+			r.Out.Host = apiServer.Host
+			stripProxyCredentials(r.Out.Header)
+			r.SetXForwarded()
+		},
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			if location := resp.Header.Get("Location"); location != "" {
+				resp.Header.Set("Location", rewriteProxyLocation(location, apiServer, apiPrefix, publicPrefix))
+			}
+			// 경로의 프록시 토큰이 Referer 로 외부 사이트에 전달되지 않도록 함
+			if resp.Header.Get("Referrer-Policy") == "" {
+				resp.Header.Set("Referrer-Policy", "same-origin")
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("프록시 요청 실패 (%s/%s/%s): %v", namespace, kind, target, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "proxy error: " + err.Error()})
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
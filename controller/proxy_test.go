@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- [ ProxyHandler 테스트 ] ---
+
+func TestCleanProxyPath(t *testing.T) {
+	assert.Equal(t, "/", cleanProxyPath(""))
+	assert.Equal(t, "/", cleanProxyPath("/"))
+	assert.Equal(t, "/static/app.js", cleanProxyPath("/static//app.js"))
+	assert.Equal(t, "/ui/", cleanProxyPath("/ui/"))
+	assert.Equal(t, "/secrets", cleanProxyPath("/../../../secrets"))
+}
+
+func TestRewriteProxyLocation(t *testing.T) {
+	apiServer, _ := url.Parse("https://10.0.0.1:6443")
+	apiPrefix := "/api/v1/namespaces/ns1/pods/web:8080/proxy"
+	publicPrefix := "/proxy/c1/ns1/pods/web:8080"
+
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{"/login?next=%2F", publicPrefix + "/login?next=%2F"},
+		{apiPrefix + "/dashboard/", publicPrefix + "/dashboard/"},
+		{"https://10.0.0.1:6443" + apiPrefix + "/home", publicPrefix + "/home"},
+		{publicPrefix + "/already", publicPrefix + "/already"},
+		{"relative/path", "relative/path"},
+		{"https://sso.example.com/authorize", "https://sso.example.com/authorize"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, rewriteProxyLocation(tt.location, apiServer, apiPrefix, publicPrefix), tt.location)
+	}
+}
+
+func TestStripProxyCredentials(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer user-jwt")
+	header.Set("Sec-WebSocket-Protocol", "graphql-ws, bearer, user-jwt")
+	stripProxyCredentials(header)
+	assert.Empty(t, header.Get("Authorization"))
+	assert.Equal(t, "graphql-ws", header.Get("Sec-WebSocket-Protocol"))
+
+	header.Set("Sec-WebSocket-Protocol", "bearer, user-jwt")
+	stripProxyCredentials(header)
+	assert.Empty(t, header.Values("Sec-WebSocket-Protocol"))
+}
+
+func TestProxyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	// pods/proxy 하위 리소스를 흉내 내는 API 서버
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer vault-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/namespaces/ns1/pods/web:8080/proxy/":
+			io.WriteString(w, "index for "+r.Header.Get("X-Forwarded-Host"))
+		case "/api/v1/namespaces/ns1/pods/web:8080/proxy/admin":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/api/v1/namespaces/ns1/services/grafana:http/proxy/api/live/ws":
+			assert.Equal(t, "grafana-live", r.Header.Get("Sec-WebSocket-Protocol"))
+			conn, err := (&websocket.Upgrader{Subprotocols: []string{"grafana-live"}}).Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+			msgType, msg, err := conn.ReadMessage()
+			require.NoError(t, err)
+			conn.WriteMessage(msgType, append([]byte("echo:"), msg...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer apiServer.Close()
+
+	apiServerURL := apiServer.URL
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		assert.Equal(t, "c1", cID)
+		assert.Equal(t, "ns1", ns)
+		return model.ClusterCredential{APIServerURL: apiServerURL, BearerToken: "vault-token"}, nil
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER"})
+		c.Next()
+	})
+	r.Any("/proxy/:clusterId/:namespace/:kind/:target/*path", ProxyHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }}
+	get := func(t *testing.T, path string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer user-jwt")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("Success - Pod port", func(t *testing.T) {
+		resp := get(t, "/proxy/c1/ns1/pods/web:8080/")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "index for "+strings.TrimPrefix(server.URL, "http://"), string(body))
+	})
+
+	t.Run("Success - Redirect rewritten", func(t *testing.T) {
+		resp := get(t, "/proxy/c1/ns1/pods/web:8080/admin")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/proxy/c1/ns1/pods/web:8080/login", resp.Header.Get("Location"))
+	})
+
+	t.Run("Success - Service websocket upgrade", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Sec-WebSocket-Protocol", "grafana-live, bearer, user-jwt")
+		conn, resp, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/proxy/c1/ns1/services/grafana:http/api/live/ws", header)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, "grafana-live", resp.Header.Get("Sec-WebSocket-Protocol"))
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("subscribe")))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "echo:subscribe", string(msg))
+	})
+
+	t.Run("Failure - Invalid namespace, kind or target", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get(t, "/proxy/c1/ns1/secrets/web:8080/").StatusCode)
+		assert.Equal(t, http.StatusBadRequest, get(t, "/proxy/c1/ns1/pods/Web_1:8080/").StatusCode)
+		assert.Equal(t, http.StatusBadRequest, get(t, "/proxy/c1/NS_1/pods/web:8080/").StatusCode)
+		assert.Equal(t, http.StatusBadRequest, get(t, "/proxy/c1/-ns1/pods/web:8080/").StatusCode)
+	})
+
+	t.Run("Failure - API server unreachable", func(t *testing.T) {
+		apiServerURL = "http://127.0.0.1:1"
+		defer func() { apiServerURL = apiServer.URL }()
+		assert.Equal(t, http.StatusBadGateway, get(t, "/proxy/c1/ns1/pods/web:8080/").StatusCode)
+	})
+}
+
+func TestProxyTokenHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{JwtSecret: "proxy-test-secret"}
+	t.Cleanup(func() { config.Env = &config.EnvConfigs{} })
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/ns1/pods/web:8080/proxy/":
+			io.WriteString(w, `<script src="static/app.js"></script>`)
+		case "/api/v1/namespaces/ns1/pods/web:8080/proxy/static/app.js":
+			io.WriteString(w, "console.log(1)")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer apiServer.Close()
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		assert.Equal(t, "c1", cID)
+		assert.Equal(t, "ws-user", uID)
+		assert.Equal(t, "USER", uType)
+		return model.ClusterCredential{APIServerURL: apiServer.URL, BearerToken: "vault-token"}, nil
+	})
+
+	jwtExpiry := time.Now().Add(5 * time.Minute)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.FullPath() == "/proxy-tokens" {
+			c.Set("claims", jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER", "exp": float64(jwtExpiry.Unix())})
+		}
+		c.Next()
+	})
+	r.POST("/proxy-tokens", CreateProxyTokenHandler)
+	r.Any("/proxy-token/:token/*path", ProxyTokenHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	issue := func(t *testing.T, body string) (*http.Response, proxyTokenResponse) {
+		resp, err := http.Post(server.URL+"/proxy-tokens", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var issued proxyTokenResponse
+		json.NewDecoder(resp.Body).Decode(&issued)
+		return resp, issued
+	}
+
+	t.Run("Success - Browser navigation and subresources", func(t *testing.T) {
+		resp, issued := issue(t, `{"clusterId":"c1","namespace":"ns1","kind":"pods","target":"web:8080"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.True(t, strings.HasPrefix(issued.URL, "/proxy-token/"))
+		// 호출자의 JWT 만료 시각을 넘지 않음
+		assert.False(t, issued.ExpiresAt.After(jwtExpiry))
+
+		// 쿠키나 헤더 없이 토큰 경로만으로 접근
+		page, err := http.Get(server.URL + issued.URL)
+		require.NoError(t, err)
+		defer page.Body.Close()
+		assert.Equal(t, http.StatusOK, page.StatusCode)
+		assert.Equal(t, "same-origin", page.Header.Get("Referrer-Policy"))
+
+		script, err := http.Get(server.URL + issued.URL + "static/app.js")
+		require.NoError(t, err)
+		defer script.Body.Close()
+		body, _ := io.ReadAll(script.Body)
+		assert.Equal(t, "console.log(1)", string(body))
+	})
+
+	t.Run("Failure - Invalid target", func(t *testing.T) {
+		resp, _ := issue(t, `{"clusterId":"c1","namespace":"../kube-system","kind":"pods","target":"web:8080"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Failure - Forged, expired or API tokens", func(t *testing.T) {
+		sign := func(claims jwt.Claims, key []byte) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(key)
+			require.NoError(t, err)
+			return token
+		}
+		valid := proxyTokenClaims{UserAuthId: "ws-user", UserType: "USER", ClusterId: "c1", Namespace: "ns1", Kind: "pods", Target: "web:8080",
+			RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{proxyTokenAudience}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}}
+		expired := valid
+		expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+		for name, token := range map[string]string{
+			"garbage":        "not-a-token",
+			"expired":        sign(expired, proxyTokenKey()),
+			"api jwt secret": sign(valid, []byte(config.Env.JwtSecret)),
+			"api jwt":        sign(jwt.MapClaims{"userAuthId": "ws-user", "userType": "USER", "exp": float64(time.Now().Add(time.Minute).Unix())}, proxyTokenKey()),
+		} {
+			resp, err := http.Get(server.URL + "/proxy-token/" + token + "/")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, name)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"cp-remote-access-api/internal/recording"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 녹화 대상 세션이면 녹화기를 생성. 대상이 아니면 nil 반환
+func startRecording(meta recording.Metadata, size remotecommand.TerminalSize) (*recording.Recorder, error) {
+	cfg := recording.ConfigFromEnv()
+	if !cfg.ShouldRecord(meta.Cluster, meta.UserType) {
+		return nil, nil
+	}
+	return recording.NewRecorder(cfg, meta, size.Width, size.Height)
+}
+
+func recordingStore() *recording.Store {
+	return recording.NewStore(recording.ConfigFromEnv().Dir)
+}
+
+func ListRecordingsHandler(c *gin.Context) {
+	filter := recording.Filter{
+		User:      c.Query("user"),
+		Cluster:   c.Query("clusterId"),
+		Namespace: c.Query("namespace"),
+		Pod:       c.Query("pod"),
+	}
+	for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " (RFC3339 required): " + value})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	recordings, err := recordingStore().List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list recordings: " + err.Error()})
+		return
+	}
+	scope := adminClusterScope(c)
+	visible := []recording.Metadata{}
+	for _, meta := range recordings {
+		if scope.allows(meta.Cluster) {
+			visible = append(visible, meta)
+		}
+	}
+	c.JSON(http.StatusOK, visible)
+}
+
+// 범위 밖 클러스터의 녹화는 존재 여부를 드러내지 않도록 ErrNotFound 로 처리
+func scopedRecording(c *gin.Context, id string) (recording.Metadata, error) {
+	meta, err := recordingStore().Get(id)
+	if err != nil {
+		return recording.Metadata{}, err
+	}
+	if !adminClusterScope(c).allows(meta.Cluster) {
+		return recording.Metadata{}, recording.ErrNotFound
+	}
+	return meta, nil
+}
+
+func GetRecordingHandler(c *gin.Context) {
+	meta, err := scopedRecording(c, c.Param("id"))
+	if errors.Is(err, recording.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recording not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read recording: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// .cast 내용 반환. start/end (초) 지정 시 해당 구간만 반환
+func GetRecordingCastHandler(c *gin.Context) {
+	start, err := parseOffset(c.Query("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start offset: " + c.Query("start")})
+		return
+	}
+	end, err := parseOffset(c.Query("end"))
+	if err != nil || (end > 0 && end < start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end offset: " + c.Query("end")})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := scopedRecording(c, id); errors.Is(err, recording.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recording not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read recording: " + err.Error()})
+		return
+	}
+	file, err := recordingStore().Open(id)
+	if errors.Is(err, recording.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recording not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open recording: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", "application/x-asciicast")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.cast"`, id))
+	if start == 0 && end == 0 {
+		c.Status(http.StatusOK)
+		io.Copy(c.Writer, file)
+		return
+	}
+	c.Status(http.StatusOK)
+	if err := recording.WriteRange(c.Writer, file, start, end); err != nil {
+		log.Printf("녹화 구간 전송 실패 (%s): %v", id, err)
+	}
+}
+
+func parseOffset(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	offset, err := strconv.ParseFloat(value, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset: %s", value)
+	}
+	return offset, nil
+}
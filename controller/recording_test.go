@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/recording"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- [ 녹화 조회 API 테스트 ] ---
+
+func setupRecordingRouter(t *testing.T, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	config.Env = &config.EnvConfigs{RecordingDir: dir}
+	t.Cleanup(func() { config.Env = &config.EnvConfigs{} })
+
+	startedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, meta := range []recording.Metadata{
+		{ID: "rec1", User: "u1", Cluster: "c1", Namespace: "ns1", Pod: "p1", StartedAt: startedAt},
+		{ID: "rec2", User: "u2", Cluster: "c2", Namespace: "ns2", Pod: "p2", StartedAt: startedAt.Add(time.Hour)},
+	} {
+		recorder, err := recording.NewRecorder(&recording.Config{Dir: dir}, meta, 80, 24)
+		require.NoError(t, err)
+		recorder.Output([]byte("hello"))
+		require.NoError(t, recorder.Close())
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", claims)
+		c.Next()
+	})
+	r.GET("/recordings", ListRecordingsHandler)
+	r.GET("/recordings/:id", GetRecordingHandler)
+	r.GET("/recordings/:id/cast", GetRecordingCastHandler)
+	return r
+}
+
+func TestRecordingHandlers(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		path                 string
+		expectedStatus       int
+		expectedBodyContains []string
+	}{
+		{
+			name:                 "List - filter by user",
+			path:                 "/recordings?user=u2",
+			expectedStatus:       http.StatusOK,
+			expectedBodyContains: []string{`"id":"rec2"`},
+		},
+		{
+			name:                 "List - filter by time range",
+			path:                 "/recordings?to=2026-03-01T10:30:00Z",
+			expectedStatus:       http.StatusOK,
+			expectedBodyContains: []string{`"id":"rec1"`},
+		},
+		{
+			name:                 "List - invalid time",
+			path:                 "/recordings?from=yesterday",
+			expectedStatus:       http.StatusBadRequest,
+			expectedBodyContains: []string{"Invalid from"},
+		},
+		{
+			name:                 "Get - metadata",
+			path:                 "/recordings/rec1",
+			expectedStatus:       http.StatusOK,
+			expectedBodyContains: []string{`"cluster":"c1"`, `"endedAt"`},
+		},
+		{
+			name:                 "Get - not found",
+			path:                 "/recordings/nope",
+			expectedStatus:       http.StatusNotFound,
+			expectedBodyContains: []string{"Recording not found"},
+		},
+		{
+			name:                 "Cast - whole",
+			path:                 "/recordings/rec1/cast",
+			expectedStatus:       http.StatusOK,
+			expectedBodyContains: []string{`"version":2`, `"o","hello"`},
+		},
+		{
+			name:                 "Cast - range past end",
+			path:                 "/recordings/rec1/cast?start=1000000000",
+			expectedStatus:       http.StatusOK,
+			expectedBodyContains: []string{`"version":2`},
+		},
+		{
+			name:                 "Cast - invalid range",
+			path:                 "/recordings/rec1/cast?start=10&end=5",
+			expectedStatus:       http.StatusBadRequest,
+			expectedBodyContains: []string{"Invalid end offset"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := setupRecordingRouter(t, jwt.MapClaims{"userAuthId": "admin-1", "userType": "SUPER_ADMIN"})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			for _, expected := range tc.expectedBodyContains {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			if tc.name == "List - filter by user" || tc.name == "List - filter by time range" {
+				assert.Equal(t, 1, strings.Count(w.Body.String(), `"id":`))
+			}
+			if tc.name == "Cast - range past end" {
+				assert.NotContains(t, w.Body.String(), `"hello"`)
+			}
+		})
+	}
+}
+
+// TestRecordingHandlers_ClusterScope: CLUSTER_ADMIN 은 자격 증명이 있는 클러스터의 녹화만 조회
+func TestRecordingHandlers_ClusterScope(t *testing.T) {
+	original := hasClusterAdminCredential
+	t.Cleanup(func() { hasClusterAdminCredential = original })
+	hasClusterAdminCredential = func(clusterID, userAuthId string) bool {
+		return userAuthId == "admin-c1" && clusterID == "c1"
+	}
+	r := setupRecordingRouter(t, jwt.MapClaims{"userAuthId": "admin-c1", "userType": "CLUSTER_ADMIN"})
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"Get - own cluster", "/recordings/rec1", http.StatusOK},
+		{"Get - other cluster", "/recordings/rec2", http.StatusNotFound},
+		{"Cast - own cluster", "/recordings/rec1/cast", http.StatusOK},
+		{"Cast - other cluster", "/recordings/rec2/cast", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.NotContains(t, w.Body.String(), `"cluster":"c2"`)
+		})
+	}
+
+	t.Run("List - other clusters hidden", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/recordings", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"rec1"`)
+		assert.NotContains(t, w.Body.String(), `"id":"rec2"`)
+	})
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/session"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+var SessionRegistry = session.NewRegistry()
+
+const controlTypeObserver = "observer"
+
+// 관전자 참여/이탈 시 세션 소유자에게 보내는 제어 메시지
+type observerMessage struct {
+	Type     string `json:"type"`
+	Event    string `json:"event"`
+	User     string `json:"user"`
+	Watchers int    `json:"watchers"`
+}
+
+func claimUserID(c *gin.Context, fallback string) string {
+	val, _ := c.Get("claims")
+	if claims, ok := val.(jwt.MapClaims); ok {
+		if userId, ok := claims["userAuthId"].(string); ok {
+			return userId
+		}
+	}
+	return fallback
+}
+
+func ListSessionsHandler(c *gin.Context) {
+	scope := adminClusterScope(c)
+	infos := []session.Info{}
+	for _, info := range SessionRegistry.List() {
+		if scope.allows(info.Cluster) {
+			infos = append(infos, info)
+		}
+	}
+	c.JSON(http.StatusOK, infos)
+}
+
+// 호출자가 관리하는 클러스터의 세션만 반환. 범위 밖 세션은 없는 것으로 처리
+func scopedSession(c *gin.Context, id string) (*session.Session, bool) {
+	sess, ok := SessionRegistry.Get(id)
+	if !ok || !adminClusterScope(c).allows(sess.Info().Cluster) {
+		return nil, false
+	}
+	return sess, true
+}
+
+// 세션 강제 종료. exec 스트림을 취소하고 close frame 과 함께 웹소켓을 닫음
+func TerminateSessionHandler(c *gin.Context) {
+	sess, ok := scopedSession(c, c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	sess.Terminate(session.Termination{
+		Reason:  exitReasonTerminated,
+		Message: fmt.Sprintf("session terminated by %s", claimUserID(c, "administrator")),
+	})
+	c.JSON(http.StatusOK, sess.Info())
+}
+
+// 진행 중인 세션의 출력을 읽기 전용으로 관전. 참여 시 스크롤백을 먼저 전송
+func WatchSessionHandler(c *gin.Context) {
+	sess, ok := scopedSession(c, c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	conn, err := Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	stream := newWatcherStream(conn)
+	watcher, snapshot, ok := sess.Watch(claimUserID(c, "unknown"))
+	if !ok {
+		stream.close(websocket.CloseNormalClosure, "session ended")
+		return
+	}
+	defer sess.Unwatch(watcher)
+
+	if len(snapshot) > 0 {
+		if _, err := stream.Write(snapshot); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case p, ok := <-watcher.Output:
+			if !ok {
+				if watcher.Lagged {
+					stream.close(websocket.CloseTryAgainLater, "watcher too slow")
+				} else {
+					stream.close(websocket.CloseNormalClosure, "session ended")
+				}
+				return
+			}
+			if _, err := stream.Write(p); err != nil {
+				return
+			}
+		case <-stream.done:
+			return
+		}
+	}
+}
+
+func sessionAuditEvent(eventType string, info session.Info) audit.Event {
+	return audit.Event{
+		Type:      eventType,
+		SessionID: info.ID,
+		Kind:      info.Kind,
+		Node:      info.Node,
+		User:      info.User,
+		UserType:  info.UserType,
+		Cluster:   info.Cluster,
+		Namespace: info.Namespace,
+		Pod:       info.Pod,
+		Container: info.Container,
+		ClientIP:  info.ClientIP,
+	}
+}
@@ -0,0 +1,500 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/session"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// --- [ 세션 레지스트리 API 테스트 ] ---
+
+// waitForSessions: 레지스트리에 지정 개수의 세션이 등록될 때까지 대기
+func waitForSessions(t *testing.T, count int) []session.Info {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if infos := SessionRegistry.List(); len(infos) == count {
+			return infos
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d active sessions", count)
+	return nil
+}
+
+func TestSessionHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			options.Stdout.Write([]byte("$ "))
+			<-ctx.Done()
+			return ctx.Err()
+		}}, nil
+	})
+
+	clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	defer clientConn.Close()
+	_, msg, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "$ ", string(msg))
+
+	infos := waitForSessions(t, 1)
+	assert.Equal(t, "ws-user", infos[0].User)
+	assert.Equal(t, "p1", infos[0].Pod)
+	assert.Equal(t, int64(2), infos[0].BytesOut)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "admin-1", "userType": "SUPER_ADMIN"})
+		c.Next()
+	})
+	r.GET("/sessions", ListSessionsHandler)
+	r.DELETE("/sessions/:id", TerminateSessionHandler)
+
+	t.Run("List active sessions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sessions", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var listed []session.Info
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+		require.Len(t, listed, 1)
+		assert.Equal(t, infos[0].ID, listed[0].ID)
+		assert.Equal(t, "ns1", listed[0].Namespace)
+	})
+
+	t.Run("Terminate unknown session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/sessions/unknown", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("CLUSTER_ADMIN of another cluster", func(t *testing.T) {
+		original := hasClusterAdminCredential
+		t.Cleanup(func() { hasClusterAdminCredential = original })
+		hasClusterAdminCredential = func(clusterID, userAuthId string) bool { return clusterID == "c2" }
+
+		scoped := gin.New()
+		scoped.Use(func(c *gin.Context) {
+			c.Set("claims", jwt.MapClaims{"userAuthId": "admin-c2", "userType": "CLUSTER_ADMIN"})
+			c.Next()
+		})
+		scoped.GET("/sessions", ListSessionsHandler)
+		scoped.DELETE("/sessions/:id", TerminateSessionHandler)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sessions", nil)
+		scoped.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodDelete, "/sessions/"+infos[0].ID, nil)
+		scoped.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		waitForSessions(t, 1)
+	})
+
+	t.Run("Terminate active session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/sessions/"+infos[0].ID, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, msg, err := clientConn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"exit","reason":"Terminated","message":"session terminated by admin-1"}`, string(msg))
+
+		_, _, err = clientConn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+
+		waitForSessions(t, 0)
+	})
+}
+
+func TestWatchSessionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	release := make(chan struct{})
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+			options.Stdout.Write([]byte("history"))
+			<-release
+			options.Stdout.Write([]byte("live"))
+			return nil
+		}}, nil
+	})
+
+	owner := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	defer owner.Close()
+	_, msg, err := owner.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "history", string(msg))
+	infos := waitForSessions(t, 1)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": "sre-1", "userType": "SUPER_ADMIN"})
+		c.Next()
+	})
+	r.GET("/ws/sessions/:id/watch", WatchSessionHandler)
+	watchServer := httptest.NewServer(r)
+	defer watchServer.Close()
+
+	t.Run("Failure - Unknown session", func(t *testing.T) {
+		resp, err := http.Get(watchServer.URL + "/ws/sessions/unknown/watch")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Failure - Session on another cluster", func(t *testing.T) {
+		original := hasClusterAdminCredential
+		t.Cleanup(func() { hasClusterAdminCredential = original })
+		hasClusterAdminCredential = func(clusterID, userAuthId string) bool { return clusterID == "c2" }
+
+		scoped := gin.New()
+		scoped.Use(func(c *gin.Context) {
+			c.Set("claims", jwt.MapClaims{"userAuthId": "admin-c2", "userType": "CLUSTER_ADMIN"})
+			c.Next()
+		})
+		scoped.GET("/ws/sessions/:id/watch", WatchSessionHandler)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/ws/sessions/"+infos[0].ID+"/watch", nil)
+		scoped.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	wsURL := strings.Replace(watchServer.URL, "http", "ws", 1) + "/ws/sessions/" + infos[0].ID + "/watch"
+	spectator, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer spectator.Close()
+
+	// 관전자는 스크롤백 스냅샷을 먼저 수신
+	_, msg, err = spectator.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "history", string(msg))
+
+	// 세션 소유자는 관전 알림을 수신
+	_, msg, err = owner.ReadMessage()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"observer","event":"joined","user":"sre-1","watchers":1}`, string(msg))
+
+	// 관전자의 입력은 무시됨
+	require.NoError(t, spectator.WriteMessage(websocket.TextMessage, []byte("rm -rf /\r")))
+	close(release)
+
+	_, msg, err = spectator.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "live", string(msg))
+
+	_, _, err = spectator.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseNormalClosure, closeErr.Code)
+	assert.Equal(t, "session ended", closeErr.Text)
+
+	_, msg, err = owner.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "live", string(msg))
+	_, msg, err = owner.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), `"reason":"Completed"`)
+}
+
+// TestSessionIdleTimeout: 유휴 세션은 터미널 경고 후 close code 4408 로 종료되고 감사 로그에 사유가 남음
+func TestSessionIdleTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	var mu sync.Mutex
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	monkey.Patch(session.LimitsFromEnv, func(cluster, userType string) session.Limits {
+		return session.Limits{IdleTimeout: 300 * time.Millisecond, Warning: 200 * time.Millisecond}
+	})
+
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			options.Stdout.Write([]byte("$ "))
+			<-ctx.Done()
+			return ctx.Err()
+		}}, nil
+	})
+
+	clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	defer clientConn.Close()
+	_, msg, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "$ ", string(msg))
+
+	_, msg, err = clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "Disconnecting in")
+
+	_, msg, err = clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "Disconnected: session idle for 300ms")
+
+	_, msg, err = clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), `"reason":"IdleTimeout"`)
+
+	_, _, err = clientConn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, closeCodeTimeout, closeErr.Code)
+	assert.Equal(t, "session idle for 300ms", closeErr.Text)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, audit.EventSessionStart, events[0].Type)
+	assert.Equal(t, audit.EventSessionEnd, events[1].Type)
+	assert.Equal(t, "IdleTimeout", events[1].Reason)
+	assert.Equal(t, "ws-user", events[1].User)
+	assert.Equal(t, "p1", events[1].Pod)
+}
+
+// TestSessionCommandAudit: 터미널 입력에서 제출된 명령 줄마다 감사 로그가 남고 비밀번호 입력은 제외됨
+func TestSessionCommandAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	var mu sync.Mutex
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			// 줄마다 프롬프트 출력. 두 번째 줄은 비밀번호 프롬프트
+			prompts := []string{"$ ", "Password: ", "$ "}
+			options.Stdout.Write([]byte(prompts[0]))
+			buf := make([]byte, 64)
+			for line := 1; line < len(prompts); {
+				n, err := options.Stdin.Read(buf)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(string(buf[:n]), "\r") {
+					options.Stdout.Write([]byte("\r\n" + prompts[line]))
+					line++
+				}
+			}
+			return nil
+		}}, nil
+	})
+
+	clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	defer clientConn.Close()
+	_, msg, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "$ ", string(msg))
+
+	require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte("su -m app\x1b[D\x1b[D\x1b[D\x1b[D\x7fl\r")))
+	_, msg, err = clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "\r\nPassword: ", string(msg))
+	require.NoError(t, clientConn.WriteMessage(websocket.TextMessage, []byte("hunter2\r")))
+
+	for {
+		_, msg, err = clientConn.ReadMessage()
+		require.NoError(t, err)
+		if strings.Contains(string(msg), `"reason":"Completed"`) {
+			break
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 3)
+	assert.Equal(t, audit.EventSessionStart, events[0].Type)
+	assert.Equal(t, audit.EventCommand, events[1].Type)
+	assert.Equal(t, map[string]interface{}{"command": "su -l app"}, events[1].Details)
+	assert.Equal(t, events[0].SessionID, events[1].SessionID)
+	assert.Equal(t, "ws-user", events[1].User)
+	assert.Equal(t, "c1", events[1].Cluster)
+	assert.Equal(t, "ns1", events[1].Namespace)
+	assert.Equal(t, "p1", events[1].Pod)
+	assert.Equal(t, "c1", events[1].Container)
+	assert.False(t, events[1].Time.IsZero())
+	assert.Equal(t, audit.EventSessionEnd, events[2].Type)
+}
+
+// TestSessionClientDisconnect: 클라이언트 연결이 끊기면 exec 스트림이 취소되고 세션이 정리됨
+func TestSessionClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	ended := make(chan audit.Event, 1)
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		if event.Type == audit.EventSessionEnd {
+			ended <- event
+		}
+	})
+
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	cancelled := make(chan struct{})
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			options.Stdout.Write([]byte("$ "))
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		}}, nil
+	})
+
+	clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	_, _, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	waitForSessions(t, 1)
+	clientConn.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("exec 스트림이 취소되지 않음")
+	}
+	select {
+	case event := <-ended:
+		assert.Equal(t, "Disconnected", event.Reason, event.Message)
+	case <-time.After(2 * time.Second):
+		t.Fatal("세션 종료 감사 이벤트 없음")
+	}
+	waitForSessions(t, 0)
+}
+
+// TestSessionServerShutdown: 서버 종료 안내가 터미널에 표시되고 close code 1001 로 종료
+func TestSessionServerShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	monkey.Patch(audit.Log, func(event audit.Event) {})
+
+	server := setupTestServer(t)
+	defer server.Close()
+
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			options.Stdout.Write([]byte("$ "))
+			<-ctx.Done()
+			return ctx.Err()
+		}}, nil
+	})
+
+	clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1")
+	defer clientConn.Close()
+	_, _, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	waitForSessions(t, 1)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		SessionRegistry.Drain(context.Background(), 50*time.Millisecond, "Server restarting.",
+			session.Termination{Reason: session.ReasonServerShutdown, Message: "server restarting"})
+	}()
+
+	_, msg, err := clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "Server restarting.")
+
+	_, msg, err = clientConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), `"reason":"ServerShutdown"`)
+
+	_, _, err = clientConn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	assert.Equal(t, "server restarting", closeErr.Text)
+
+	<-drained
+	assert.Equal(t, 0, SessionRegistry.Len())
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	shellAuto    = "auto"
+	defaultShell = "/bin/sh"
+)
+
+const controlTypeShell = "shell"
+
+var defaultAllowedShells = []string{"/bin/bash", "/bin/sh", "/bin/ash", "/busybox/sh", "/bin/zsh"}
+
+// auto 모드 탐색 순서: bash → sh → ash → busybox sh
+var shellFallbackChain = []string{"/bin/bash", "/bin/sh", "/bin/ash", "/busybox/sh"}
+
+var errShellNotFound = errors.New("no usable shell found")
+
+type shellMessage struct {
+	Type  string `json:"type"`
+	Shell string `json:"shell"`
+}
+
+func allowedShells() []string {
+	if config.Env != nil && config.Env.ExecShells != "" {
+		return config.SplitList(config.Env.ExecShells)
+	}
+	return defaultAllowedShells
+}
+
+// shell 파라미터를 허용 목록의 실행 경로로 변환 (전체 경로 또는 bash 같은 이름 허용)
+func resolveShell(requested string) (string, error) {
+	switch requested {
+	case "":
+		return defaultShell, nil
+	case shellAuto:
+		return shellAuto, nil
+	}
+	for _, allowed := range allowedShells() {
+		if requested == allowed || requested == path.Base(allowed) {
+			return allowed, nil
+		}
+	}
+	return "", fmt.Errorf("shell not allowed: %s", requested)
+}
+
+func autoShellCandidates() []string {
+	allowed := allowedShells()
+	var candidates []string
+	for _, shell := range shellFallbackChain {
+		for _, a := range allowed {
+			if a == shell {
+				candidates = append(candidates, shell)
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+func shellExecOptions(container, shell string) *corev1.PodExecOptions {
+	return &corev1.PodExecOptions{
+		Container: container,
+		Command:   []string{shell},
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       true,
+	}
+}
+
+// 후보 shell 을 비대화형으로 실행해 처음 성공한 shell 을 반환
+func detectShell(clientset kubernetes.Interface, cfg *rest.Config, pod, namespace, container string) (string, error) {
+	candidates := autoShellCandidates()
+	for _, shell := range candidates {
+		executor, err := newExecutor(clientset, cfg, pod, namespace, &corev1.PodExecOptions{
+			Container: container,
+			Command:   []string{shell, "-c", "exit 0"},
+			Stdout:    true,
+			Stderr:    true,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdout: io.Discard,
+			Stderr: io.Discard,
+		})
+		cancel()
+		if err == nil {
+			return shell, nil
+		}
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || isContainerNotRunningError(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%w (tried %s)", errShellNotFound, strings.Join(candidates, ", "))
+}
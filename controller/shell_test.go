@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/model"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// --- [ resolveShell 테스트 ] ---
+
+func TestResolveShell(t *testing.T) {
+	testCases := []struct {
+		name          string
+		execShells    string
+		requested     string
+		expectedShell string
+		expectedError string
+	}{
+		{name: "Default - /bin/sh", requested: "", expectedShell: "/bin/sh"},
+		{name: "Auto mode", requested: "auto", expectedShell: shellAuto},
+		{name: "Full path in default allow-list", requested: "/bin/bash", expectedShell: "/bin/bash"},
+		{name: "Base name in default allow-list", requested: "zsh", expectedShell: "/bin/zsh"},
+		{name: "Configured allow-list", execShells: "/bin/sh, /usr/bin/fish", requested: "fish", expectedShell: "/usr/bin/fish"},
+		{name: "Not allowed", execShells: "/bin/sh", requested: "/bin/bash", expectedError: "shell not allowed: /bin/bash"},
+		{name: "Not allowed - arbitrary command", requested: "/usr/bin/python3", expectedError: "shell not allowed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.Env = &config.EnvConfigs{ExecShells: tc.execShells}
+
+			shell, err := resolveShell(tc.requested)
+
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedShell, shell)
+			}
+		})
+	}
+}
+
+// --- [ ExecWebSocketHandler shell 선택 테스트 ] ---
+
+func TestExecWebSocketHandler_ShellSelection(t *testing.T) {
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+
+	t.Run("Success - Auto mode falls back to first available shell", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		config.Env = &config.EnvConfigs{}
+		server := setupTestServer(t)
+		defer server.Close()
+
+		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+			return model.ClusterCredential{BearerToken: "token"}, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+		var probed []string
+		var started []string
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			if !opts.TTY {
+				probed = append(probed, opts.Command[0])
+				return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+					if opts.Command[0] == "/bin/ash" {
+						return nil
+					}
+					return errors.New("executable file not found in $PATH")
+				}}, nil
+			}
+			started = opts.Command
+			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+				options.Stdout.Write([]byte("$ "))
+				return nil
+			}}, nil
+		})
+
+		clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1&shell=auto")
+		defer clientConn.Close()
+
+		_, msg, err := clientConn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"shell","shell":"/bin/ash"}`, string(msg))
+
+		_, msg, err = clientConn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "$ ", string(msg))
+
+		assert.Equal(t, []string{"/bin/bash", "/bin/sh", "/bin/ash"}, probed)
+		assert.Equal(t, []string{"/bin/ash"}, started)
+	})
+
+	t.Run("Failure - Auto mode finds no shell", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		config.Env = &config.EnvConfigs{ExecShells: "/bin/bash,/bin/sh"}
+		server := setupTestServer(t)
+		defer server.Close()
+
+		monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+			return model.ClusterCredential{BearerToken: "token"}, nil
+		})
+		K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+		monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+			return &FakeExecutor{StreamFunc: func(options remotecommand.StreamOptions) error {
+				return errors.New("executable file not found in $PATH")
+			}}, nil
+		})
+
+		clientConn := clientDial(t, server.URL, "?pod=p1&namespace=ns1&container=c1&clusterId=c1&shell=auto")
+		defer clientConn.Close()
+
+		_, msg, err := clientConn.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type":"exit","reason":"ShellNotFound","message":"no usable shell found (tried /bin/bash, /bin/sh)"}`, string(msg))
+
+		_, _, err = clientConn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, closeCodeShellNotFound, closeErr.Code)
+	})
+
+	t.Run("Failure - Shell not in allow-list (before upgrade)", func(t *testing.T) {
+		t.Cleanup(monkey.UnpatchAll)
+		config.Env = &config.EnvConfigs{}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/ws/exec?clusterId=c1&shell="+strings.ReplaceAll("/usr/bin/python3", "/", "%2F"), nil)
+		c.Set("claims", jwt.MapClaims{"userAuthId": "u1", "userType": "USER"})
+
+		ExecWebSocketHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "shell not allowed")
+	})
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/recording"
+	"cp-remote-access-api/internal/session"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	sessionKindExec   = "exec"
+	sessionKindAttach = "attach"
+)
+
+// 대화형 websocket 세션 대상
+type terminalTarget struct {
+	Kind      string
+	Node      string
+	User      string
+	UserType  string
+	Cluster   string
+	Namespace string
+	Pod       string
+	Container string
+	Shell     string
+	ClientIP  string
+}
+
+func newTerminalTarget(c *gin.Context, claims jwt.MapClaims, kind string) terminalTarget {
+	return terminalTarget{
+		Kind:      kind,
+		User:      claims["userAuthId"].(string),
+		UserType:  claims["userType"].(string),
+		Cluster:   c.Query("clusterId"),
+		Namespace: c.Query("namespace"),
+		Pod:       c.Query("pod"),
+		Container: c.Query("container"),
+		ClientIP:  c.ClientIP(),
+	}
+}
+
+// 스트림 모드. attach 는 컨테이너 설정(stdin, tty)을 따름
+type terminalMode struct {
+	Stdin bool
+	Tty   bool
+}
+
+// 녹화, 세션 등록, 유휴/최대 시간 감시, 감사 로그를 적용해 스트림을 실행하고 최종 상태를 전달
+func runTerminalSession(parent context.Context, wsStream *webSocketStream, clientset kubernetes.Interface, target terminalTarget,
+	initialSize remotecommand.TerminalSize, executor remotecommand.Executor, mode terminalMode) {
+	sessionID := newSessionID()
+	recorder, err := startRecording(recording.Metadata{
+		ID:        sessionID,
+		Kind:      target.Kind,
+		Node:      target.Node,
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Namespace: target.Namespace,
+		Pod:       target.Pod,
+		Container: target.Container,
+		Shell:     target.Shell,
+		StartedAt: time.Now(),
+	}, initialSize)
+	if err != nil {
+		log.Printf("세션 녹화 시작 실패: %v", err)
+		wsStream.writeExit(execExit{Type: controlTypeExit, Reason: exitReasonError, Message: "failed to start session recording"})
+		return
+	}
+	if recorder != nil {
+		wsStream.addObserver(recorder)
+		defer recorder.Close()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	sess := session.New(session.Info{
+		ID:        sessionID,
+		Kind:      target.Kind,
+		Node:      target.Node,
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Namespace: target.Namespace,
+		Pod:       target.Pod,
+		Container: target.Container,
+		Shell:     target.Shell,
+		ClientIP:  target.ClientIP,
+	}, func(session.Termination) { cancel() })
+	sess.OnWatch(func(notice session.WatchNotice) {
+		wsStream.writeJSON(observerMessage{Type: controlTypeObserver, Event: notice.Event, User: notice.User, Watchers: notice.Watchers})
+	})
+	sess.OnNotice(func(text string) { wsStream.writeNotice(text) })
+	wsStream.addObserver(sess)
+	if mode.Stdin {
+		wsStream.addObserver(commandAuditor(sess.Info()))
+	}
+	SessionRegistry.Add(sess)
+	defer SessionRegistry.Remove(sessionID)
+	defer sess.Close()
+	audit.Log(sessionAuditEvent(audit.EventSessionStart, sess.Info()))
+
+	// 클라이언트 연결이 끊기면(pong 미수신 포함) 스트림 정리
+	go func() {
+		select {
+		case <-wsStream.done:
+			sess.Terminate(session.Termination{Reason: exitReasonDisconnected, Message: "client connection lost"})
+		case <-ctx.Done():
+		}
+	}()
+
+	limits := session.LimitsFromEnv(target.Cluster, target.UserType)
+	go sess.Enforce(ctx, limits, func(warning session.Warning) {
+		wsStream.writeNotice(warning.Message)
+	})
+
+	options := remotecommand.StreamOptions{
+		Stdout: wsStream,
+		Stderr: wsStream.stderr(),
+		Tty:    mode.Tty,
+	}
+	if mode.Stdin {
+		options.Stdin = wsStream
+	} else {
+		wsStream.discardStdin()
+	}
+	if mode.Tty {
+		options.TerminalSizeQueue = wsStream
+	}
+
+	startedAt := time.Now()
+	err = executor.StreamWithContext(ctx, options)
+	if recorder != nil {
+		recorder.Close()
+	}
+
+	exit := describeExecExit(clientset, target.Namespace, target.Pod, target.Container, startedAt, err)
+	if err != nil && wsStream.closed() {
+		// 요청 컨텍스트가 먼저 취소된 경우에도 연결 끊김으로 기록
+		sess.Terminate(session.Termination{Reason: exitReasonDisconnected, Message: "client connection lost"})
+	}
+	if termination, ok := sess.Termination(); ok {
+		exit = execExit{Type: controlTypeExit, Reason: termination.Reason, Message: termination.Message}
+		if termination.Reason == session.ReasonIdleTimeout || termination.Reason == session.ReasonMaxDurationExceeded {
+			wsStream.writeNotice("Disconnected: " + termination.Message)
+		}
+	}
+	endEvent := sessionAuditEvent(audit.EventSessionEnd, sess.Info())
+	endEvent.Reason = exit.Reason
+	endEvent.Message = exit.Message
+	audit.Log(endEvent)
+	wsStream.writeExit(exit)
+}
+
+// 입력에서 추출한 명령 줄마다 감사 로그 기록
+func commandAuditor(info session.Info) *audit.CommandExtractor {
+	return audit.NewCommandExtractor(func(command audit.Command) {
+		event := sessionAuditEvent(audit.EventCommand, info)
+		event.Time = time.Now()
+		event.Details = map[string]interface{}{"command": command.Line}
+		if command.Approximate {
+			event.Details["approximate"] = true
+		}
+		if command.Pasted {
+			event.Details["pasted"] = true
+		}
+		audit.Log(event)
+	})
+}
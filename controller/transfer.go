@@ -0,0 +1,382 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/transfer"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var TransferRegistry = transfer.NewRegistry()
+
+const (
+	defaultTransferChunkBytes = 8 << 20
+	defaultTransferTTL        = 24 * time.Hour
+	transferVerifyTimeout     = 30 * time.Minute
+
+	auditEventFileTransfer = "files.transfer"
+)
+
+// 구간 읽기 스크립트. $1 경로, $2 시작 위치, $3 길이
+// dd 의 바이트 단위 skip/count (GNU, 최신 busybox) 를 우선 사용하고 없으면 tail -c | head -c
+const transferReadScript = `if dd if=/dev/null of=/dev/null iflag=skip_bytes,count_bytes count=0 2>/dev/null; then
+	exec dd if="$1" iflag=skip_bytes,count_bytes skip="$2" count="$3" bs=65536
+fi
+tail -c +$(($2 + 1)) -- "$1" | head -c "$3"`
+
+func transferChunkBytes() int64 {
+	if config.Env != nil && config.Env.TransferChunkBytes > 0 {
+		return config.Env.TransferChunkBytes
+	}
+	return defaultTransferChunkBytes
+}
+
+func transferTTL() time.Duration {
+	if config.Env != nil && config.Env.TransferTTLSeconds > 0 {
+		return time.Duration(config.Env.TransferTTLSeconds) * time.Second
+	}
+	return defaultTransferTTL
+}
+
+// 전송 시작 시점의 파일을 식별. 파일이 바뀌면 If-Range 가 일치하지 않음
+func transferETag(info transfer.Info) string {
+	return fmt.Sprintf(`"%s-%d-%d"`, info.ID, info.Size, info.ModTime.UnixNano())
+}
+
+// 요청한 Range 의 시작 위치. Range 가 없으면 0. 여러 구간은 지원하지 않음
+// 형식이 잘못된 Range 는 0 을 반환해 http.ServeContent 가 416 으로 응답하도록 함
+func transferRangeStart(header string, size int64) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, nil
+	}
+	if strings.Contains(spec, ",") {
+		return 0, errors.New("multiple ranges are not supported")
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(spec), "-")
+	if first == "" {
+		// 끝에서부터 N 바이트 (bytes=-N)
+		_, last, _ := strings.Cut(spec, "-")
+		n, err := strconv.ParseInt(strings.TrimSpace(last), 10, 64)
+		if err != nil || n >= size {
+			return 0, nil
+		}
+		return size - n, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return start, nil
+}
+
+// 컨테이너 파일을 청크 단위 exec 로 읽는 ReadSeeker (http.ServeContent 용)
+// 청크마다 별도 exec 이므로 큰 파일도 exec 한 번의 시간 제한에 걸리지 않음
+type containerFileReader struct {
+	ctx      context.Context
+	target   *fileTarget
+	transfer *transfer.Transfer
+	path     string
+	size     int64
+	chunk    int64
+	offset   int64
+
+	body      *io.PipeReader
+	bodyEnd   int64
+	cancel    context.CancelFunc
+	done      chan fileExecResult
+	startedAt time.Time
+	// 응답을 중단시킨 읽기 오류 (ServeContent 는 오류를 반환하지 않음)
+	err error
+}
+
+func (r *containerFileReader) open() {
+	end := r.offset + r.chunk
+	if end > r.size {
+		end = r.size
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	pr, pw := io.Pipe()
+	done := make(chan fileExecResult, 1)
+	command := []string{"sh", "-c", transferReadScript, "sh", r.path, strconv.FormatInt(r.offset, 10), strconv.FormatInt(end-r.offset, 10)}
+	go func() {
+		stderr, err := r.target.exec(ctx, command, nil, pw)
+		pw.CloseWithError(err)
+		done <- fileExecResult{stderr, err}
+	}()
+	r.body, r.bodyEnd, r.cancel, r.done, r.startedAt = pr, end, cancel, done, time.Now()
+}
+
+// 현재 청크 정리. abort 면 남은 출력을 기다리지 않고 중단
+func (r *containerFileReader) closeBody(abort bool) fileExecResult {
+	if r.body == nil {
+		return fileExecResult{}
+	}
+	if abort {
+		r.cancel()
+		r.body.Close()
+	} else {
+		io.Copy(io.Discard, r.body)
+	}
+	result := <-r.done
+	r.cancel()
+	r.body = nil
+	return result
+}
+
+func (r *containerFileReader) Read(p []byte) (int, error) {
+	n, err := r.read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *containerFileReader) read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		r.open()
+	}
+	if remaining := r.bodyEnd - r.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.transfer.Sent(r.offset, p[:n])
+		r.offset += int64(n)
+	}
+	if err == nil && r.offset < r.bodyEnd {
+		return n, nil
+	}
+
+	result := r.closeBody(err != nil && err != io.EOF)
+	if result.err != nil {
+		_, message := r.target.execError("dd", result.stderr, r.startedAt, result.err)
+		return n, errors.New(message)
+	}
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	if r.offset < r.bodyEnd {
+		return n, fmt.Errorf("%s: file is shorter than %d bytes", r.path, r.size)
+	}
+	return n, nil
+}
+
+func (r *containerFileReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset {
+		r.closeBody(true)
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *containerFileReader) Close() error {
+	r.closeBody(true)
+	return nil
+}
+
+func (t *fileTarget) transferAudit(info transfer.Info) audit.Event {
+	details := map[string]interface{}{"transferId": info.ID, "path": info.Path, "size": info.Size, "status": info.Status}
+	if info.SHA256 != "" {
+		details["sha256"] = info.SHA256
+	}
+	if info.Error != "" {
+		details["error"] = info.Error
+	}
+	return t.auditEvent(auditEventFileTransfer, details)
+}
+
+// 끝까지 전송된 뒤 컨테이너에서 sha256 을 계산해 전송한 내용과 비교
+func verifyTransfer(target *fileTarget, tr *transfer.Transfer) {
+	ctx, cancel := context.WithTimeout(context.Background(), transferVerifyTimeout)
+	defer cancel()
+
+	info := tr.Info()
+	out := &limitedBuffer{limit: fileStderrLimit}
+	startedAt := time.Now()
+	stderr, err := target.exec(ctx, []string{"sha256sum", info.Path}, nil, out)
+	switch fields := strings.Fields(out.String()); {
+	case err != nil:
+		_, message := target.execError("sha256sum", stderr, startedAt, err)
+		tr.Fail("verification failed: " + message)
+	case len(fields) == 0:
+		tr.Fail("verification failed: unexpected sha256sum output")
+	default:
+		tr.Verify(fields[0])
+	}
+	info = tr.Info()
+	if info.Status == transfer.StatusFailed {
+		log.Printf("파일 전송 검증 실패 (%s/%s %s): %s", info.Namespace, info.Pod, info.Path, info.Error)
+	}
+	audit.Log(target.transferAudit(info))
+}
+
+// POST /files/transfers?path=. 파일 크기와 수정 시각을 기록하고 전송 ID 발급
+func CreateTransferHandler(c *gin.Context) {
+	p, err := containerPath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, ok := newFileTarget(c)
+	if !ok {
+		return
+	}
+
+	entry, code, message := target.stat(c.Request.Context(), p)
+	switch {
+	case code != 0:
+		c.JSON(code, gin.H{"error": message})
+		return
+	case entry.Type == "symlink":
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is a symbolic link to %s", p, entry.Target)})
+		return
+	case entry.Type != "file":
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is not a regular file", p)})
+		return
+	}
+
+	TransferRegistry.Prune(transferTTL())
+	tr := transfer.New(transfer.Info{
+		ID:        newSessionID(),
+		User:      target.User,
+		UserType:  target.UserType,
+		Cluster:   target.Cluster,
+		Namespace: target.Namespace,
+		Pod:       target.Pod,
+		Container: target.Container,
+		Path:      p,
+		Size:      entry.Size,
+		ModTime:   entry.ModTime,
+	})
+	TransferRegistry.Add(tr)
+	audit.Log(target.transferAudit(tr.Info()))
+	c.JSON(http.StatusCreated, tr.Info())
+}
+
+// 요청 사용자의 전송만 조회 가능. 없으면 404 응답을 작성
+func userTransfer(c *gin.Context) (*transfer.Transfer, bool) {
+	tr, ok := TransferRegistry.Get(c.Param("id"))
+	if !ok || tr.Info().User != claimUserID(c, "") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return nil, false
+	}
+	return tr, true
+}
+
+// GET /files/transfers. 요청 사용자의 전송 목록
+func ListTransfersHandler(c *gin.Context) {
+	TransferRegistry.Prune(transferTTL())
+	c.JSON(http.StatusOK, TransferRegistry.List(claimUserID(c, "")))
+}
+
+// GET /files/transfers/:id. 진행 상황
+func GetTransferHandler(c *gin.Context) {
+	tr, ok := userTransfer(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, tr.Info())
+}
+
+// GET /files/transfers/:id/content. Range 요청으로 이어받기 지원
+// 해시를 순서대로 계산하므로 구간은 이미 받은 위치(transferred) 이하에서 시작해야 함 (병렬 구간 다운로드 불가)
+func TransferContentHandler(c *gin.Context) {
+	tr, ok := userTransfer(c)
+	if !ok {
+		return
+	}
+	info := tr.Info()
+	if info.Status == transfer.StatusFailed {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("transfer failed: %s; create a new transfer", info.Error)})
+		return
+	}
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	target := &fileTarget{
+		User:      info.User,
+		UserType:  claims["userType"].(string),
+		Cluster:   info.Cluster,
+		Namespace: info.Namespace,
+		Pod:       info.Pod,
+		Container: info.Container,
+		ClientIP:  c.ClientIP(),
+	}
+	if !target.connect(c) {
+		return
+	}
+
+	// 시작 이후 파일이 바뀌었으면 이어받은 내용이 섞이므로 거부
+	entry, code, message := target.stat(c.Request.Context(), info.Path)
+	if code != 0 {
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	if entry.Size != info.Size || !entry.ModTime.Equal(info.ModTime) {
+		tr.Fail("file changed since the transfer started")
+		audit.Log(target.transferAudit(tr.Info()))
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": fmt.Sprintf("%s changed since the transfer started; create a new transfer", info.Path)})
+		return
+	}
+
+	// If-Range 가 이 전송의 ETag 가 아니면 구간 요청을 무시하고 전체를 보냄
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != transferETag(info) {
+		c.Request.Header.Del("Range")
+	}
+	start, err := transferRangeStart(c.GetHeader("Range"), info.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if start > info.Transferred {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("ranges must be requested in order; resume from byte %d", info.Transferred)})
+		return
+	}
+
+	reader := &containerFileReader{
+		ctx:      c.Request.Context(),
+		target:   target,
+		transfer: tr,
+		path:     info.Path,
+		size:     info.Size,
+		chunk:    transferChunkBytes(),
+	}
+	defer reader.Close()
+
+	// Content-Type 을 지정해 내용 추측용 읽기를 생략
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", attachmentHeader(path.Base(info.Path)))
+	c.Header("ETag", transferETag(info))
+	http.ServeContent(c.Writer, c.Request, path.Base(info.Path), info.ModTime, reader)
+	if reader.err != nil && c.Request.Context().Err() == nil {
+		// 응답이 잘린 채 끝나므로 클라이언트는 Range 로 이어받음
+		log.Printf("파일 전송 중단 (%s/%s %s, offset %d): %v", info.Namespace, info.Pod, info.Path, reader.offset, reader.err)
+	}
+
+	if tr.Complete() {
+		go verifyTransfer(target, tr)
+	}
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/internal/audit"
+	"cp-remote-access-api/internal/transfer"
+	"cp-remote-access-api/model"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bouk/monkey"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// --- [ 파일 전송 테스트 ] ---
+
+func TestTransferHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Env = &config.EnvConfigs{TransferChunkBytes: 16}
+	t.Cleanup(monkey.UnpatchAll)
+	monkey.Patch(log.Printf, func(format string, v ...interface{}) {})
+	var auditMu sync.Mutex
+	var events []audit.Event
+	monkey.Patch(audit.Log, func(event audit.Event) {
+		auditMu.Lock()
+		defer auditMu.Unlock()
+		events = append(events, event)
+	})
+	monkey.Patch(GetClusterInfo, func(cID, uID, uType, ns string) (model.ClusterCredential, error) {
+		return model.ClusterCredential{BearerToken: "token"}, nil
+	})
+	K8sClientFactoryImpl = &fakeK8sClientFactory{clientset: fake.NewSimpleClientset()}
+
+	// 컨테이너의 /dump/core 파일 흉내
+	content := strings.Repeat("0123456789abcdef", 6) + "tail"
+	var mu sync.Mutex
+	fileType, mtime, checksum := "f", "1792184775.5", fileChecksum([]byte(content))
+	var chunks [][2]int64
+	var failChunkAt int64 = -1
+	monkey.Patch(newExecutor, func(cs kubernetes.Interface, cfg *rest.Config, p, n string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		command := opts.Command
+		return &contextExecutor{StreamFunc: func(ctx context.Context, options remotecommand.StreamOptions) error {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case command[0] == "sha256sum":
+				_, err := io.WriteString(options.Stdout, checksum+"  "+command[1]+"\n")
+				return err
+			case command[2] == fileListScript:
+				if command[4] != "/dump/core" {
+					io.WriteString(options.Stderr, command[4]+": No such file or directory")
+					return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 2}
+				}
+				_, err := io.WriteString(options.Stdout, nulJoin("find", "core", fileType, strconv.Itoa(len(content)), "600", "root", "root", mtime, "../etc/passwd"))
+				return err
+			case command[2] == transferReadScript:
+				offset, _ := strconv.ParseInt(command[5], 10, 64)
+				length, _ := strconv.ParseInt(command[6], 10, 64)
+				chunks = append(chunks, [2]int64{offset, length})
+				if offset == failChunkAt {
+					io.WriteString(options.Stderr, "dd: error reading '/dump/core': Input/output error")
+					return exec.CodeExitError{Err: errors.New("command terminated with non-zero exit code"), Code: 1}
+				}
+				_, err := io.WriteString(options.Stdout, content[offset:offset+length])
+				return err
+			}
+			return errors.New("unexpected command")
+		}}, nil
+	})
+
+	user := "file-user"
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("claims", jwt.MapClaims{"userAuthId": user, "userType": "USER"})
+		c.Next()
+	})
+	r.POST("/files/transfers", CreateTransferHandler)
+	r.GET("/files/transfers", ListTransfersHandler)
+	r.GET("/files/transfers/:id", GetTransferHandler)
+	r.GET("/files/transfers/:id/content", TransferContentHandler)
+	serve := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	create := func(t *testing.T) transfer.Info {
+		w := serve(http.MethodPost, "/files/transfers?path=/dump/core&pod=p1&namespace=ns1&container=app&clusterId=c1", nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var info transfer.Info
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		return info
+	}
+	status := func(id string) transfer.Info {
+		tr, _ := TransferRegistry.Get(id)
+		return tr.Info()
+	}
+	rangeHeader := func(value string) http.Header {
+		return http.Header{"Range": []string{value}}
+	}
+
+	t.Run("Success - Full download in chunks", func(t *testing.T) {
+		chunks = nil
+		info := create(t)
+		assert.Equal(t, int64(100), info.Size)
+		assert.Equal(t, transfer.StatusPending, info.Status)
+		assert.Equal(t, "p1", info.Pod)
+
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.String())
+		assert.Equal(t, "100", w.Header().Get("Content-Length"))
+		assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+		assert.Equal(t, `attachment; filename=core`, w.Header().Get("Content-Disposition"))
+		assert.Len(t, chunks, 7)
+		assert.Equal(t, [2]int64{96, 4}, chunks[6])
+
+		assert.Eventually(t, func() bool { return status(info.ID).Status == transfer.StatusVerified }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, checksum, status(info.ID).SHA256)
+		auditMu.Lock()
+		last := events[len(events)-1]
+		auditMu.Unlock()
+		assert.Equal(t, auditEventFileTransfer, last.Type)
+		assert.Equal(t, transfer.StatusVerified, last.Details["status"])
+	})
+
+	t.Run("Success - Resume after disconnect", func(t *testing.T) {
+		chunks = nil
+		info := create(t)
+
+		// 40 바이트에서 끊김
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=0-39"))
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, content[:40], w.Body.String())
+		progress := status(info.ID)
+		assert.Equal(t, int64(40), progress.Transferred)
+		assert.Equal(t, transfer.StatusRunning, progress.Status)
+
+		// 청크 중간에서 이어받기. ETag 가 같으면 If-Range 도 통과
+		etag := w.Header().Get("ETag")
+		w = serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", http.Header{"Range": []string{"bytes=40-"}, "If-Range": []string{etag}})
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "bytes 40-99/100", w.Header().Get("Content-Range"))
+		assert.Equal(t, content[40:], w.Body.String())
+		assert.Equal(t, [2]int64{40, 16}, chunks[3])
+
+		assert.Eventually(t, func() bool { return status(info.ID).Status == transfer.StatusVerified }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(100), status(info.ID).BytesSent)
+	})
+
+	t.Run("Failure - Read error truncates response", func(t *testing.T) {
+		failChunkAt = 32
+		defer func() { failChunkAt = -1 }()
+		info := create(t)
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", nil)
+		assert.Equal(t, "100", w.Header().Get("Content-Length"))
+		assert.Equal(t, content[:32], w.Body.String())
+		// 재시도 가능한 상태로 유지
+		progress := status(info.ID)
+		assert.Equal(t, int64(32), progress.Transferred)
+		assert.Equal(t, transfer.StatusRunning, progress.Status)
+	})
+
+	t.Run("Failure - Checksum mismatch", func(t *testing.T) {
+		info := create(t)
+		checksum = fileChecksum([]byte("changed"))
+		defer func() { checksum = fileChecksum([]byte(content)) }()
+		serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", nil)
+		assert.Eventually(t, func() bool { return status(info.ID).Status == transfer.StatusFailed }, time.Second, 10*time.Millisecond)
+		assert.Contains(t, status(info.ID).Error, "sha256 mismatch")
+
+		// 실패한 전송은 다시 내려받을 수 없음
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "create a new transfer")
+	})
+
+	t.Run("Failure - Range beyond resume point", func(t *testing.T) {
+		chunks = nil
+		info := create(t)
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=0-39"))
+		require.Equal(t, http.StatusPartialContent, w.Code)
+
+		// 병렬 구간 다운로드는 받은 위치 이후부터 시작할 수 없음
+		w = serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=50-"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "resume from byte 40")
+		w = serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=-10"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=40-49,60-69")).Code)
+
+		// If-Range 가 일치하지 않으면 구간을 무시하고 전체를 보냄
+		w = serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", http.Header{"Range": []string{"bytes=50-"}, "If-Range": []string{`"stale"`}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.String())
+		assert.Eventually(t, func() bool { return status(info.ID).Status == transfer.StatusVerified }, time.Second, 10*time.Millisecond)
+		assert.Len(t, chunks, 3+7)
+	})
+
+	t.Run("Failure - File changed since transfer started", func(t *testing.T) {
+		info := create(t)
+		mtime = "1792184999"
+		defer func() { mtime = "1792184775.5" }()
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", rangeHeader("bytes=50-"))
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, transfer.StatusFailed, status(info.ID).Status)
+	})
+
+	t.Run("Failure - Not a regular file", func(t *testing.T) {
+		fileType = "l"
+		w := serve(http.MethodPost, "/files/transfers?path=/dump/core&pod=p1&namespace=ns1&clusterId=c1", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		fileType = "d"
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/files/transfers?path=/dump/core&pod=p1&namespace=ns1&clusterId=c1", nil).Code)
+		fileType = "f"
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/files/transfers?path=/dump/missing&pod=p1&namespace=ns1&clusterId=c1", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/files/transfers?path=dump/core&pod=p1&namespace=ns1&clusterId=c1", nil).Code)
+	})
+
+	t.Run("Progress visible only to owner", func(t *testing.T) {
+		info := create(t)
+		w := serve(http.MethodGet, "/files/transfers/"+info.ID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"Pending"`)
+
+		var list []transfer.Info
+		require.NoError(t, json.Unmarshal(serve(http.MethodGet, "/files/transfers", nil).Body.Bytes(), &list))
+		assert.NotEmpty(t, list)
+
+		user = "other-user"
+		defer func() { user = "file-user" }()
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/files/transfers/"+info.ID, nil).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/files/transfers/"+info.ID+"/content", nil).Code)
+		assert.Equal(t, "[]", serve(http.MethodGet, "/files/transfers", nil).Body.String())
+	})
+}
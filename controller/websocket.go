@@ -1,48 +1,466 @@
 package controller
 
 import (
-	"github.com/gorilla/websocket"
+	"cp-remote-access-api/config"
+	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
+const bearerProtocol = "bearer"
+
+const (
+	defaultPingInterval    = 30 * time.Second
+	defaultPongWait        = 60 * time.Second
+	defaultWriteQueueBytes = 256 * 1024
+	defaultWriteTimeout    = 10 * time.Second
+	controlWriteWait       = time.Second
+)
+
+// 채널 프로토콜: 바이너리 프레임의 첫 바이트가 채널 번호 (stdin 0, stdout 1, stderr 2, error 3, resize 4, close 255)
+var channelProtocols = []string{
+	remotecommandconsts.StreamProtocolV5Name,
+	remotecommandconsts.StreamProtocolV4Name,
+	remotecommandconsts.StreamProtocolV1Name,
+}
+
 var Upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true }, // CORS 허용
+	CheckOrigin:  func(r *http.Request) bool { return true }, // CORS 허용
+	Subprotocols: append(append([]string{}, channelProtocols...), bearerProtocol),
+}
+
+// 연결 유지 및 쓰기 제한
+type streamLimits struct {
+	pingInterval    time.Duration
+	pongWait        time.Duration
+	writeQueueBytes int
+	writeTimeout    time.Duration
+}
+
+// WS_PING_INTERVAL_SECONDS, WS_PONG_WAIT_SECONDS, WS_WRITE_QUEUE_BYTES, WS_WRITE_TIMEOUT_SECONDS.
+// ping 주기는 pong 대기 시간보다 짧아야 함
+func streamLimitsFromEnv() streamLimits {
+	limits := streamLimits{
+		pingInterval:    defaultPingInterval,
+		pongWait:        defaultPongWait,
+		writeQueueBytes: defaultWriteQueueBytes,
+		writeTimeout:    defaultWriteTimeout,
+	}
+	if config.Env != nil {
+		if config.Env.WsPingIntervalSeconds > 0 {
+			limits.pingInterval = time.Duration(config.Env.WsPingIntervalSeconds) * time.Second
+		}
+		if config.Env.WsPongWaitSeconds > 0 {
+			limits.pongWait = time.Duration(config.Env.WsPongWaitSeconds) * time.Second
+		}
+		if config.Env.WsWriteQueueBytes > 0 {
+			limits.writeQueueBytes = config.Env.WsWriteQueueBytes
+		}
+		if config.Env.WsWriteTimeoutSeconds > 0 {
+			limits.writeTimeout = time.Duration(config.Env.WsWriteTimeoutSeconds) * time.Second
+		}
+	}
+	if limits.pingInterval >= limits.pongWait {
+		limits.pingInterval = limits.pongWait / 2
+	}
+	return limits
+}
+
+func isChannelProtocol(protocol string) bool {
+	for _, p := range channelProtocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+const controlTypeResize = "resize"
+
+// 클라이언트 → 서버 제어 메시지 (예: {"type":"resize","cols":120,"rows":40})
+type controlMessage struct {
+	Type string `json:"type"`
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+func parseControlMessage(msg []byte) (controlMessage, bool) {
+	var ctrl controlMessage
+	if len(msg) == 0 || msg[0] != '{' {
+		return ctrl, false
+	}
+	if err := json.Unmarshal(msg, &ctrl); err != nil || ctrl.Type == "" {
+		return ctrl, false
+	}
+	return ctrl, true
+}
+
+func terminalSizeFromQuery(c *gin.Context) (remotecommand.TerminalSize, bool) {
+	cols, err := strconv.ParseUint(c.Query("cols"), 10, 16)
+	if err != nil || cols == 0 {
+		return remotecommand.TerminalSize{}, false
+	}
+	rows, err := strconv.ParseUint(c.Query("rows"), 10, 16)
+	if err != nil || rows == 0 {
+		return remotecommand.TerminalSize{}, false
+	}
+	return remotecommand.TerminalSize{Width: uint16(cols), Height: uint16(rows)}, true
+}
+
+// 최신 크기만 유지하는 TerminalSizeQueue 구현
+type terminalSizeQueue struct {
+	mu     sync.Mutex
+	ch     chan remotecommand.TerminalSize
+	closed bool
+}
+
+func newTerminalSizeQueue() *terminalSizeQueue {
+	return &terminalSizeQueue{ch: make(chan remotecommand.TerminalSize, 1)}
+}
+
+func (q *terminalSizeQueue) push(size remotecommand.TerminalSize) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	select {
+	case <-q.ch:
+	default:
+	}
+	q.ch <- size
+}
+
+func (q *terminalSizeQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+}
+
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.ch
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+// 세션 입출력 관찰자 (녹화 등)
+type streamObserver interface {
+	Input(p []byte)
+	Output(p []byte)
+	Resize(width, height uint16)
 }
 
 type webSocketStream struct {
-	conn   *websocket.Conn
-	readCh chan []byte
+	conn       *websocket.Conn
+	readCh     chan []byte
+	sizes      *terminalSizeQueue
+	channelled bool
+	// 관전자용: 수신 프레임을 모두 버림
+	readOnly bool
+	// 포트 포워딩용: 바이너리 프레임을 제어 메시지나 채널 번호 없이 그대로 주고받음
+	raw bool
+	// 읽기 고루틴 종료(연결 끊김, pong 미수신) 시 닫힘
+	done chan struct{}
+	// 스트림 종료 시 닫힘. 이후 수신한 입력은 소비자가 없으므로 버림
+	quit     chan struct{}
+	quitOnce sync.Once
+	pongWait time.Duration
+	out      *writePump
+	// 읽기 고루틴에서만 접근
+	stdinClosed bool
+	// Read 호출자만 접근
+	pending []byte
+	// stdin 을 받지 않는 세션(attach 대상 컨테이너에 stdin 이 없는 경우). resize 는 계속 처리
+	stdinDisabled atomic.Bool
+
+	observerMu sync.RWMutex
+	observers  []streamObserver
 }
 
 func newWebSocketStream(conn *websocket.Conn) *webSocketStream {
-	s := &webSocketStream{
-		conn:   conn,
-		readCh: make(chan []byte),
-	}
+	return newStream(conn, false, streamLimitsFromEnv())
+}
+
+// 출력 전용 스트림 (stdin, resize 무시)
+func newWatcherStream(conn *websocket.Conn) *webSocketStream {
+	return newStream(conn, true, streamLimitsFromEnv())
+}
+
+// TCP 스트림 중계용. 제어 메시지는 텍스트 프레임으로만 전송하고, 클라이언트는 빈 바이너리 프레임으로 쓰기 종료를 알림
+func newRawStream(conn *websocket.Conn) *webSocketStream {
+	s := &webSocketStream{raw: true}
+	return s.start(conn, streamLimitsFromEnv())
+}
+
+func newStream(conn *websocket.Conn, readOnly bool, limits streamLimits) *webSocketStream {
+	s := &webSocketStream{readOnly: readOnly}
+	return s.start(conn, limits)
+}
+
+func (s *webSocketStream) start(conn *websocket.Conn, limits streamLimits) *webSocketStream {
+	s.conn = conn
+	s.readCh = make(chan []byte)
+	s.sizes = newTerminalSizeQueue()
+	s.channelled = !s.raw && isChannelProtocol(conn.Subprotocol())
+	s.done = make(chan struct{})
+	s.quit = make(chan struct{})
+	s.out = newWritePump(conn, limits.writeQueueBytes, limits.writeTimeout)
+	s.startKeepalive(limits.pingInterval, limits.pongWait)
+	go s.readLoop()
+	return s
+}
+
+// 주기적으로 ping 을 보내고, pongWait 동안 pong 이나 다른 프레임이 없으면 읽기 기한 만료로 readLoop 종료
+func (s *webSocketStream) startKeepalive(pingInterval, pongWait time.Duration) {
+	s.pongWait = pongWait
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
 
 	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
 		for {
-			_, msg, err := conn.ReadMessage()
-			if err != nil {
-				close(s.readCh)
+			select {
+			case <-s.done:
 				return
+			case <-ticker.C:
+				if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(controlWriteWait)); err != nil {
+					return
+				}
 			}
-			s.readCh <- msg
 		}
 	}()
+}
 
-	return s
+func (s *webSocketStream) readLoop() {
+	defer close(s.done)
+	// 클라이언트가 사라지면 남은 출력만 내보내고 쓰기 고루틴 종료
+	defer s.out.shutdown(nil)
+	defer s.sizes.close()
+	defer s.stdinEOF()
+	for {
+		msgType, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(s.pongWait))
+		if s.readOnly {
+			continue
+		}
+		if s.raw {
+			// 빈 바이너리 프레임은 클라이언트 쪽 쓰기 종료(half-close). 이후 수신 방향은 계속 중계
+			if msgType == websocket.BinaryMessage && len(msg) == 0 {
+				s.stdinEOF()
+			} else if msgType == websocket.BinaryMessage && !s.stdinClosed {
+				s.deliver(msg)
+			}
+			continue
+		}
+		if s.channelled {
+			if msgType == websocket.BinaryMessage {
+				s.handleChannelFrame(msg)
+			}
+			continue
+		}
+		if ctrl, ok := parseControlMessage(msg); ok && ctrl.Type == controlTypeResize {
+			if ctrl.Cols > 0 && ctrl.Rows > 0 {
+				s.resize(remotecommand.TerminalSize{Width: ctrl.Cols, Height: ctrl.Rows})
+			}
+			continue
+		}
+		if !s.stdinDisabled.Load() {
+			s.deliver(msg)
+		}
+	}
+}
+
+// 입력을 Read 호출자에게 전달. 소비자가 읽기를 멈춘 뒤에는 막히지 않고 버림
+func (s *webSocketStream) deliver(msg []byte) {
+	select {
+	case s.readCh <- msg:
+	case <-s.quit:
+	}
+}
+
+func (s *webSocketStream) handleChannelFrame(frame []byte) {
+	if len(frame) == 0 {
+		return
+	}
+	payload := frame[1:]
+	switch frame[0] {
+	case remotecommandconsts.StreamStdIn:
+		if len(payload) > 0 && !s.stdinClosed && !s.stdinDisabled.Load() {
+			s.deliver(payload)
+		}
+	case remotecommandconsts.StreamResize:
+		var size remotecommand.TerminalSize
+		if err := json.Unmarshal(payload, &size); err == nil && size.Width > 0 && size.Height > 0 {
+			s.resize(size)
+		}
+	case remotecommandconsts.StreamClose:
+		if s.conn.Subprotocol() == remotecommandconsts.StreamProtocolV5Name &&
+			len(payload) > 0 && payload[0] == remotecommandconsts.StreamStdIn {
+			s.stdinEOF()
+		}
+	}
+}
+
+// stdin 종료(EOF) 처리. 이후 도착하는 stdin 프레임은 버림
+func (s *webSocketStream) stdinEOF() {
+	if !s.stdinClosed {
+		s.stdinClosed = true
+		close(s.readCh)
+	}
+}
+
+// 클라이언트 연결 종료 여부
+func (s *webSocketStream) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// 이후 수신하는 stdin 데이터를 버림
+func (s *webSocketStream) discardStdin() {
+	s.stdinDisabled.Store(true)
+}
+
+func (s *webSocketStream) addObserver(o streamObserver) {
+	s.observerMu.Lock()
+	defer s.observerMu.Unlock()
+	s.observers = append(s.observers, o)
+}
+
+func (s *webSocketStream) notify(fn func(o streamObserver)) {
+	s.observerMu.RLock()
+	defer s.observerMu.RUnlock()
+	for _, o := range s.observers {
+		fn(o)
+	}
+}
+
+func (s *webSocketStream) resize(size remotecommand.TerminalSize) {
+	s.sizes.push(size)
+	s.notify(func(o streamObserver) { o.Resize(size.Width, size.Height) })
+}
+
+func (s *webSocketStream) Next() *remotecommand.TerminalSize {
+	return s.sizes.Next()
 }
 
+// p 보다 큰 프레임은 남은 부분을 다음 Read 에서 반환
 func (s *webSocketStream) Read(p []byte) (int, error) {
-	msg, ok := <-s.readCh
-	if !ok {
-		return 0, io.EOF
+	if len(s.pending) == 0 {
+		msg, ok := <-s.readCh
+		if !ok {
+			return 0, io.EOF
+		}
+		s.pending = msg
 	}
-	return copy(p, msg), nil
+	n := copy(p, s.pending)
+	read := s.pending[:n]
+	s.pending = s.pending[n:]
+	s.notify(func(o streamObserver) { o.Input(read) })
+	return n, nil
 }
 
 func (s *webSocketStream) Write(p []byte) (int, error) {
-	return len(p), s.conn.WriteMessage(websocket.TextMessage, p)
+	return s.writeChannel(remotecommandconsts.StreamStdOut, p)
+}
+
+func (s *webSocketStream) stderr() io.Writer {
+	return channelWriter{stream: s, channel: remotecommandconsts.StreamStdErr}
+}
+
+func (s *webSocketStream) writeChannel(channel byte, p []byte) (int, error) {
+	if channel == remotecommandconsts.StreamStdOut || channel == remotecommandconsts.StreamStdErr {
+		s.notify(func(o streamObserver) { o.Output(p) })
+	}
+	if err := s.out.enqueue(s.dataFrame(channel, p, true)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// 레거시 모드는 텍스트 프레임, 채널 프로토콜은 채널 번호가 붙은 바이너리 프레임
+func (s *webSocketStream) dataFrame(channel byte, p []byte, coalesce bool) outboundFrame {
+	if s.raw {
+		return outboundFrame{messageType: websocket.BinaryMessage, payload: p, coalesce: coalesce, channel: channel}
+	}
+	if !s.channelled {
+		return outboundFrame{messageType: websocket.TextMessage, payload: p, coalesce: coalesce, channel: channel}
+	}
+	return outboundFrame{messageType: websocket.BinaryMessage, channelled: true, channel: channel, payload: p, coalesce: coalesce}
+}
+
+// 다른 출력과 합치지 않고 한 프레임으로 전송 (로그 한 줄 등)
+func (s *webSocketStream) writeFrame(channel byte, p []byte) error {
+	return s.out.enqueue(s.dataFrame(channel, p, false))
+}
+
+// 서버 안내 문구를 터미널에 표시. 관찰자(녹화, 유휴 감시)에는 전달하지 않음
+func (s *webSocketStream) writeNotice(text string) error {
+	payload := []byte("\r\n\x1b[33m[cp-remote-api] " + text + "\x1b[0m\r\n")
+	return s.out.enqueue(s.dataFrame(remotecommandconsts.StreamStdOut, payload, false))
+}
+
+// 제어 메시지 전송. 채널 프로토콜에서는 데이터(바이너리 프레임)와 구분되는 텍스트 프레임.
+// 레거시 모드는 출력도 텍스트 프레임이므로 같은 JSON 을 출력하는 프로그램과 구분할 수 없음.
+// 제어 메시지에 의존하는 클라이언트는 채널 프로토콜을 사용하고, 레거시 모드에서는 close code/reason 으로 종료 상태를 판단해야 함
+func (s *webSocketStream) writeJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.writeText(string(payload))
+}
+
+func (s *webSocketStream) writeText(text string) error {
+	return s.out.enqueue(outboundFrame{messageType: websocket.TextMessage, payload: []byte(text)})
+}
+
+// 최종 종료 상태 전달 후 close frame 전송. 채널 프로토콜은 error 채널로 metav1.Status 를 전송
+func (s *webSocketStream) writeExit(exit execExit) {
+	if s.channelled {
+		payload, _ := json.Marshal(exit.status())
+		s.writeChannel(remotecommandconsts.StreamErr, payload)
+	} else {
+		s.writeJSON(exit)
+	}
+	s.close(exit.closeCode(), exit.closeText())
+}
+
+// 대기 중인 출력을 모두 보낸 뒤 close frame 전송
+func (s *webSocketStream) close(code int, text string) {
+	s.quitOnce.Do(func() { close(s.quit) })
+	s.out.shutdown(websocket.FormatCloseMessage(code, text))
+	s.out.wait()
+}
+
+type channelWriter struct {
+	stream  *webSocketStream
+	channel byte
+}
+
+func (w channelWriter) Write(p []byte) (int, error) {
+	return w.stream.writeChannel(w.channel, p)
 }
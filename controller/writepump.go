@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 합쳐진 데이터 프레임의 최대 크기
+const maxCoalescedFrame = 32 * 1024
+
+var errStreamClosed = errors.New("websocket stream closed")
+
+type outboundFrame struct {
+	messageType int
+	// 채널 프로토콜 데이터 프레임이면 payload 앞에 채널 번호를 붙임
+	channelled bool
+	channel    byte
+	payload    []byte
+	// 같은 채널의 연속된 출력은 하나의 프레임으로 합침
+	coalesce bool
+}
+
+func (f outboundFrame) data() []byte {
+	if !f.channelled {
+		return f.payload
+	}
+	frame := make([]byte, len(f.payload)+1)
+	frame[0] = f.channel
+	copy(frame[1:], f.payload)
+	return frame
+}
+
+func (f *outboundFrame) merge(next outboundFrame) bool {
+	if !f.coalesce || !next.coalesce || f.messageType != next.messageType ||
+		f.channelled != next.channelled || f.channel != next.channel ||
+		len(f.payload)+len(next.payload) > maxCoalescedFrame {
+		return false
+	}
+	f.payload = append(f.payload, next.payload...)
+	return true
+}
+
+// gorilla/websocket 은 동시 쓰기를 허용하지 않으므로 모든 데이터 프레임을 단일 고루틴에서 전송.
+// 대기열이 queueBytes 를 넘으면 생산자를 막아 느린 클라이언트의 압력을 exec 스트림까지 전달
+type writePump struct {
+	conn         *websocket.Conn
+	queueBytes   int
+	writeTimeout time.Duration
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	queue       []outboundFrame
+	queuedBytes int
+	closing     bool
+	// nil 이면 close frame 없이 종료
+	closeMsg []byte
+	err      error
+	done     chan struct{}
+}
+
+func newWritePump(conn *websocket.Conn, queueBytes int, writeTimeout time.Duration) *writePump {
+	p := &writePump{
+		conn:         conn,
+		queueBytes:   queueBytes,
+		writeTimeout: writeTimeout,
+		done:         make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	go p.run()
+	return p
+}
+
+func (p *writePump) enqueue(frame outboundFrame) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.err == nil && !p.closing && p.queuedBytes >= p.queueBytes {
+		p.cond.Wait()
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if p.closing {
+		return errStreamClosed
+	}
+
+	frame.payload = append([]byte(nil), frame.payload...)
+	if n := len(p.queue); n > 0 && p.queue[n-1].merge(frame) {
+		p.queuedBytes += len(frame.payload)
+		return nil
+	}
+	p.queue = append(p.queue, frame)
+	p.queuedBytes += len(frame.payload)
+	p.cond.Broadcast()
+	return nil
+}
+
+func (p *writePump) run() {
+	defer close(p.done)
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closing && p.err == nil {
+			p.cond.Wait()
+		}
+		if p.err != nil || len(p.queue) == 0 {
+			closeMsg := p.closeMsg
+			p.mu.Unlock()
+			if closeMsg != nil {
+				p.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(controlWriteWait))
+			}
+			return
+		}
+		frame := p.queue[0]
+		p.queue[0] = outboundFrame{}
+		p.queue = p.queue[1:]
+		p.queuedBytes -= len(frame.payload)
+		p.cond.Broadcast()
+		p.mu.Unlock()
+
+		p.conn.SetWriteDeadline(time.Now().Add(p.writeTimeout))
+		if err := p.conn.WriteMessage(frame.messageType, frame.data()); err != nil {
+			p.fail(err)
+		}
+	}
+}
+
+// 쓰기 실패 시 대기열을 버리고 대기 중인 생산자를 깨움
+func (p *writePump) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.queue = nil
+	p.queuedBytes = 0
+	p.closeMsg = nil
+	p.cond.Broadcast()
+}
+
+// 대기열을 모두 전송한 뒤 종료. closeMsg 가 있으면 마지막에 close frame 전송
+func (p *writePump) shutdown(closeMsg []byte) {
+	p.mu.Lock()
+	if !p.closing {
+		p.closing = true
+		if p.err == nil {
+			p.closeMsg = closeMsg
+		}
+		p.cond.Broadcast()
+	}
+	p.mu.Unlock()
+}
+
+// shutdown 후 전송 완료까지 대기
+func (p *writePump) wait() {
+	<-p.done
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+)
+
+// --- [ writePump 테스트 ] ---
+// 동시 쓰기 안전성은 go test -race 로 검증
+
+// newPumpTestServer: 서버 측 스트림을 채널로 넘기는 테스트 서버
+func newPumpTestServer(t *testing.T, queueBytes int, writeTimeout time.Duration, protocols ...string) (*webSocketStream, *websocket.Conn) {
+	streams := make(chan *webSocketStream, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		streams <- newStream(conn, false, streamLimits{
+			pingInterval:    time.Minute,
+			pongWait:        2 * time.Minute,
+			writeQueueBytes: queueBytes,
+			writeTimeout:    writeTimeout,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: protocols}
+	clientConn, _, err := dialer.Dial(strings.Replace(server.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { clientConn.Close() })
+
+	stream := <-streams
+	t.Cleanup(func() { stream.conn.Close() })
+	return stream, clientConn
+}
+
+func TestWritePump_ConcurrentWriters(t *testing.T) {
+	stream, clientConn := newPumpTestServer(t, 4096, 5*time.Second, remotecommandconsts.StreamProtocolV5Name)
+
+	const writers, writes = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				line := []byte(fmt.Sprintf("w%d-%d;", w, i))
+				var err error
+				switch w % 3 {
+				case 0:
+					_, err = stream.Write(line)
+				case 1:
+					_, err = stream.stderr().Write(line)
+				default:
+					err = stream.writeJSON(map[string]int{"writer": w, "seq": i})
+				}
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+
+	received := map[byte]*bytes.Buffer{
+		remotecommandconsts.StreamStdOut: {},
+		remotecommandconsts.StreamStdErr: {},
+	}
+	lastSeq := map[int]int{}
+	frames := 0
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		for {
+			msgType, msg, err := clientConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			frames++
+			if msgType == websocket.TextMessage {
+				var ctrl map[string]int
+				if assert.NoError(t, json.Unmarshal(msg, &ctrl)) {
+					// 같은 작성자의 제어 메시지 순서 유지
+					prev, seen := lastSeq[ctrl["writer"]]
+					assert.True(t, !seen || ctrl["seq"] == prev+1)
+					lastSeq[ctrl["writer"]] = ctrl["seq"]
+				}
+				continue
+			}
+			received[msg[0]].Write(msg[1:])
+		}
+	}()
+
+	wg.Wait()
+	stream.close(websocket.CloseNormalClosure, "")
+	<-readDone
+
+	for w := 0; w < writers; w++ {
+		var out string
+		switch w % 3 {
+		case 0:
+			out = received[remotecommandconsts.StreamStdOut].String()
+		case 1:
+			out = received[remotecommandconsts.StreamStdErr].String()
+		default:
+			assert.Equal(t, writes-1, lastSeq[w])
+			continue
+		}
+		// 같은 작성자의 데이터는 순서대로 모두 도착
+		pos := 0
+		for i := 0; i < writes; i++ {
+			idx := strings.Index(out[pos:], fmt.Sprintf("w%d-%d;", w, i))
+			require.GreaterOrEqual(t, idx, 0, "writer %d seq %d missing", w, i)
+			pos += idx
+		}
+	}
+	assert.LessOrEqual(t, frames, writers*writes)
+}
+
+func TestOutboundFrame_Merge(t *testing.T) {
+	stdout := outboundFrame{messageType: websocket.BinaryMessage, channelled: true, channel: remotecommandconsts.StreamStdOut, payload: []byte("a"), coalesce: true}
+
+	next := stdout
+	next.payload = []byte("b")
+	assert.True(t, stdout.merge(next))
+	assert.Equal(t, []byte{remotecommandconsts.StreamStdOut, 'a', 'b'}, stdout.data())
+
+	stderr := next
+	stderr.channel = remotecommandconsts.StreamStdErr
+	assert.False(t, stdout.merge(stderr), "다른 채널은 합치지 않음")
+
+	control := outboundFrame{messageType: websocket.TextMessage, payload: []byte(`{}`)}
+	assert.False(t, stdout.merge(control), "제어 메시지는 합치지 않음")
+
+	large := next
+	large.payload = make([]byte, maxCoalescedFrame)
+	assert.False(t, stdout.merge(large), "최대 프레임 크기 초과")
+}
+
+func TestWritePump_Backpressure(t *testing.T) {
+	stream, clientConn := newPumpTestServer(t, 64*1024, 10*time.Second)
+
+	// 클라이언트가 읽지 않으면 소켓 버퍼와 대기열이 찬 뒤 생산자가 막힘
+	const chunks = 512
+	chunk := bytes.Repeat([]byte("x"), 32*1024)
+	var written atomic.Int32
+	producerDone := make(chan struct{})
+	go func() {
+		defer close(producerDone)
+		for i := 0; i < chunks; i++ {
+			if _, err := stream.Write(chunk); err != nil {
+				return
+			}
+			written.Add(1)
+		}
+	}()
+
+	select {
+	case <-producerDone:
+		t.Fatal("느린 클라이언트에서 생산자가 막히지 않음")
+	case <-time.After(300 * time.Millisecond):
+	}
+	assert.Less(t, written.Load(), int32(chunks))
+
+	total := 0
+	for total < chunks*len(chunk) {
+		_, msg, err := clientConn.ReadMessage()
+		require.NoError(t, err)
+		total += len(msg)
+	}
+	<-producerDone
+	assert.Equal(t, int32(chunks), written.Load())
+}
+
+func TestWritePump_Shutdown(t *testing.T) {
+	t.Run("Close frame follows queued output", func(t *testing.T) {
+		stream, clientConn := newPumpTestServer(t, 4096, time.Second)
+
+		_, err := stream.Write([]byte("bye"))
+		require.NoError(t, err)
+		stream.close(websocket.CloseNormalClosure, "done")
+
+		_, msg, err := clientConn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "bye", string(msg))
+		_, _, err = clientConn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, "done", closeErr.Text)
+
+		_, err = stream.Write([]byte("late"))
+		assert.ErrorIs(t, err, errStreamClosed)
+		// 중복 close 는 무시
+		stream.close(websocket.CloseNormalClosure, "again")
+	})
+
+	t.Run("Stalled client releases blocked writers", func(t *testing.T) {
+		stream, clientConn := newPumpTestServer(t, 1024, 200*time.Millisecond)
+
+		chunk := bytes.Repeat([]byte("x"), 64*1024)
+		errCh := make(chan error, 1)
+		go func() {
+			for {
+				if _, err := stream.Write(chunk); err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}()
+
+		// 쓰기 기한 초과로 펌프가 실패하면 생산자에게 오류 반환
+		select {
+		case err := <-errCh:
+			assert.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("막힌 생산자가 해제되지 않음")
+		}
+		clientConn.Close()
+		stream.out.wait()
+	})
+}
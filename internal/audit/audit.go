@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"cp-remote-access-api/config"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	EventSessionStart = "session.start"
+	EventSessionEnd   = "session.end"
+	// 세션 입력에서 추출한 명령 줄
+	EventCommand = "session.command"
+)
+
+// 감사 이벤트 (JSON Lines 로 기록)
+type Event struct {
+	Time      time.Time              `json:"time"`
+	Type      string                 `json:"type"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Kind      string                 `json:"kind,omitempty"`
+	User      string                 `json:"user,omitempty"`
+	UserType  string                 `json:"userType,omitempty"`
+	Cluster   string                 `json:"cluster,omitempty"`
+	Node      string                 `json:"node,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Pod       string                 `json:"pod,omitempty"`
+	Container string                 `json:"container,omitempty"`
+	ClientIP  string                 `json:"clientIp,omitempty"`
+	Reason    string                 `json:"reason,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+func (l *Logger) Log(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("감사 로그 직렬화 실패: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		log.Printf("감사 로그 기록 실패: %v", err)
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultLogger *Logger
+)
+
+// AUDIT_LOG_FILE 이 설정되면 해당 파일에, 아니면 표준 출력에 기록
+func Default() *Logger {
+	defaultOnce.Do(func() {
+		var w io.Writer = os.Stdout
+		if config.Env != nil && config.Env.AuditLogFile != "" {
+			file, err := os.OpenFile(config.Env.AuditLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+			if err != nil {
+				log.Printf("감사 로그 파일 열기 실패, 표준 출력 사용: %v", err)
+			} else {
+				w = file
+			}
+		}
+		defaultLogger = NewLogger(w)
+	})
+	return defaultLogger
+}
+
+func Log(event Event) {
+	Default().Log(event)
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLogger_Log: 이벤트가 한 줄 JSON 으로 기록되는지 검증
+func TestLogger_Log(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	logger.Log(Event{Type: EventSessionEnd, SessionID: "s1", User: "u1", Reason: "IdleTimeout"})
+	logger.Log(Event{Type: EventSessionStart, Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "session.end", first["type"])
+	assert.Equal(t, "IdleTimeout", first["reason"])
+	assert.NotEmpty(t, first["time"])
+	assert.NotContains(t, first, "details")
+
+	assert.Contains(t, lines[1], `"time":"2026-01-01T00:00:00Z"`)
+}
+
+// TestLogger_Concurrent: 동시 기록 시 줄이 섞이지 않아야 함
+func TestLogger_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Log(Event{Type: EventSessionStart, Message: strings.Repeat("x", 256)})
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 50)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)))
+	}
+}
@@ -0,0 +1,432 @@
+package audit
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxCommandRunes   = 4096
+	maxCommandHistory = 500
+	// 프롬프트 판별용으로 보관하는 마지막 출력 줄 크기
+	maxPromptBytes = 256
+)
+
+// 전체 화면 프로그램(vim, less, top 등)의 대체 화면 진입/종료
+var (
+	altScreenEnter = [][]byte{[]byte("\x1b[?1049h"), []byte("\x1b[?1047h"), []byte("\x1b[?47h")}
+	altScreenLeave = [][]byte{[]byte("\x1b[?1049l"), []byte("\x1b[?1047l"), []byte("\x1b[?47l")}
+
+	ansiSequence = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|.)`)
+	// 비밀번호 등 입력이 화면에 표시되지 않는 프롬프트
+	secretPrompt = regexp.MustCompile(`(?i)(password|passphrase|passcode|secret|token|pin)[^:]*:\s*$`)
+	// 셸 명령 프롬프트 ($, #, %, > 로 끝남). read 나 y/n 질문의 응답과 구분하는 데 사용
+	shellPrompt = regexp.MustCompile(`[$#%>]\s*$`)
+)
+
+// 입력 스트림에서 추출한 명령 한 줄
+type Command struct {
+	Line string
+	// 셸이 처리해 재현할 수 없는 편집(탭 완성, 역방향 검색, 이전 세션 기록 등)이 포함된 경우
+	Approximate bool
+	// 붙여넣기(bracketed paste)로 입력된 경우
+	Pasted bool
+}
+
+type inputState int
+
+const (
+	inputText inputState = iota
+	inputEscape
+	inputCSI
+	inputSS3
+)
+
+// 터미널 입력을 readline 과 비슷하게 해석해 제출된 명령 줄을 추출.
+// 커서 이동, 백스페이스, 방향키 기록 탐색, bracketed paste 를 반영하며 Input/Output 은 동시에 호출될 수 있음
+type CommandExtractor struct {
+	mu   sync.Mutex
+	emit func(Command)
+
+	line        []rune
+	cursor      int
+	approximate bool
+	pasted      bool
+	started     bool
+	prompt      string
+
+	history []string
+	histPos int
+	draft   []rune
+
+	state  inputState
+	seq    []byte
+	utf8   []byte
+	paste  bool
+	lastCR bool
+
+	outLine   []byte
+	outTail   []byte
+	altScreen bool
+}
+
+func NewCommandExtractor(emit func(Command)) *CommandExtractor {
+	return &CommandExtractor{emit: emit}
+}
+
+func (e *CommandExtractor) Input(p []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.altScreen {
+		return
+	}
+	for _, b := range p {
+		e.inputByte(b)
+	}
+}
+
+// 출력은 프롬프트와 대체 화면 여부 판단에만 사용
+func (e *CommandExtractor) Output(p []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data := append(append([]byte{}, e.outTail...), p...)
+	enter, leave := lastIndexAny(data, altScreenEnter), lastIndexAny(data, altScreenLeave)
+	if enter > leave && !e.altScreen {
+		e.altScreen = true
+		e.resetLine()
+	} else if leave > enter {
+		e.altScreen = false
+	}
+	if len(data) > 8 {
+		data = data[len(data)-8:]
+	}
+	e.outTail = append(e.outTail[:0], data...)
+
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		e.outLine = append(e.outLine[:0], p[i+1:]...)
+	} else {
+		e.outLine = append(e.outLine, p...)
+	}
+	if len(e.outLine) > maxPromptBytes {
+		e.outLine = append(e.outLine[:0], e.outLine[len(e.outLine)-maxPromptBytes:]...)
+	}
+}
+
+func (e *CommandExtractor) Resize(width, height uint16) {}
+
+func lastIndexAny(data []byte, seqs [][]byte) int {
+	last := -1
+	for _, seq := range seqs {
+		if i := bytes.LastIndex(data, seq); i > last {
+			last = i
+		}
+	}
+	return last
+}
+
+func (e *CommandExtractor) inputByte(b byte) {
+	cr := e.lastCR
+	e.lastCR = false
+	if cr && b == '\n' && e.state == inputText && !e.paste {
+		// CRLF 의 LF
+		return
+	}
+	if !e.started {
+		// 줄의 첫 입력 시점에 화면에 있던 프롬프트
+		e.started = true
+		e.prompt = ansiSequence.ReplaceAllString(string(e.outLine), "")
+	}
+
+	switch e.state {
+	case inputEscape:
+		switch b {
+		case '[':
+			e.state, e.seq = inputCSI, e.seq[:0]
+		case 'O':
+			e.state = inputSS3
+		default:
+			e.state = inputText
+			if !e.paste {
+				e.altKey(b)
+			}
+		}
+		return
+	case inputCSI:
+		if b >= 0x40 && b <= 0x7e {
+			e.state = inputText
+			e.csi(string(e.seq), b)
+		} else if len(e.seq) < 16 {
+			e.seq = append(e.seq, b)
+		}
+		return
+	case inputSS3:
+		e.state = inputText
+		if !e.paste {
+			e.csi("", b)
+		}
+		return
+	}
+
+	switch {
+	case b == 0x1b:
+		e.state = inputEscape
+	case e.paste:
+		// 붙여넣은 줄바꿈은 실행되지 않고 줄에 포함됨
+		if b == '\r' {
+			b = '\n'
+		}
+		e.insertByte(b)
+	case b == '\r':
+		e.lastCR = true
+		e.submit()
+	case b == '\n':
+		e.submit()
+	case b < 0x20 || b == 0x7f:
+		e.control(b)
+	default:
+		e.insertByte(b)
+	}
+}
+
+func (e *CommandExtractor) insertByte(b byte) {
+	e.utf8 = append(e.utf8, b)
+	if !utf8.FullRune(e.utf8) {
+		return
+	}
+	r, _ := utf8.DecodeRune(e.utf8)
+	e.utf8 = e.utf8[:0]
+	e.insert(r)
+}
+
+func (e *CommandExtractor) insert(r rune) {
+	if len(e.line) >= maxCommandRunes {
+		e.approximate = true
+		return
+	}
+	e.line = append(e.line, 0)
+	copy(e.line[e.cursor+1:], e.line[e.cursor:])
+	e.line[e.cursor] = r
+	e.cursor++
+}
+
+func (e *CommandExtractor) control(b byte) {
+	switch b {
+	case 0x7f, 0x08: // 백스페이스
+		if e.cursor > 0 {
+			e.deleteRange(e.cursor-1, e.cursor)
+		}
+	case 0x01: // Ctrl-A
+		e.cursor = 0
+	case 0x05: // Ctrl-E
+		e.cursor = len(e.line)
+	case 0x02: // Ctrl-B
+		e.moveCursor(-1)
+	case 0x06: // Ctrl-F
+		e.moveCursor(1)
+	case 0x04: // Ctrl-D
+		if e.cursor < len(e.line) {
+			e.deleteRange(e.cursor, e.cursor+1)
+		}
+	case 0x0b: // Ctrl-K
+		e.deleteRange(e.cursor, len(e.line))
+	case 0x15: // Ctrl-U
+		e.deleteRange(0, e.cursor)
+	case 0x17: // Ctrl-W (공백 기준 단어)
+		e.deleteRange(e.wordStart(unicode.IsSpace), e.cursor)
+	case 0x03: // Ctrl-C
+		e.resetLine()
+	case 0x10: // Ctrl-P
+		e.historyPrev()
+	case 0x0e: // Ctrl-N
+		e.historyNext()
+	case 0x0c, 0x07: // Ctrl-L, 벨
+	default:
+		// 탭 완성, Ctrl-R 검색, Ctrl-Y 붙여넣기 등은 셸 상태를 알아야 재현 가능
+		e.approximate = true
+	}
+}
+
+// CSI/SS3 시퀀스. params 는 최종 문자 앞의 매개변수
+func (e *CommandExtractor) csi(params string, final byte) {
+	switch params {
+	case "200":
+		if final == '~' {
+			e.paste, e.pasted = true, true
+		}
+		return
+	case "201":
+		if final == '~' {
+			e.paste = false
+		}
+		return
+	}
+	if e.paste {
+		return
+	}
+	// 1;5C 등 수식키 조합은 단어 단위 이동
+	word := strings.Contains(params, ";")
+	switch final {
+	case 'A':
+		e.historyPrev()
+	case 'B':
+		e.historyNext()
+	case 'C':
+		if word {
+			e.cursor = e.wordEnd()
+		} else {
+			e.moveCursor(1)
+		}
+	case 'D':
+		if word {
+			e.cursor = e.wordStart(isNotWordRune)
+		} else {
+			e.moveCursor(-1)
+		}
+	case 'H':
+		e.cursor = 0
+	case 'F':
+		e.cursor = len(e.line)
+	case '~':
+		switch params {
+		case "1", "7":
+			e.cursor = 0
+		case "4", "8":
+			e.cursor = len(e.line)
+		case "3":
+			if e.cursor < len(e.line) {
+				e.deleteRange(e.cursor, e.cursor+1)
+			}
+		}
+	}
+}
+
+// ESC 다음 문자 (Alt 조합)
+func (e *CommandExtractor) altKey(b byte) {
+	switch b {
+	case 'b':
+		e.cursor = e.wordStart(isNotWordRune)
+	case 'f':
+		e.cursor = e.wordEnd()
+	case 0x7f, 0x08:
+		e.deleteRange(e.wordStart(isNotWordRune), e.cursor)
+	case 'd':
+		e.deleteRange(e.cursor, e.wordEnd())
+	default:
+		e.approximate = true
+	}
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// 커서 앞 단어의 시작 위치
+func (e *CommandExtractor) wordStart(separator func(rune) bool) int {
+	i := e.cursor
+	for i > 0 && separator(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && !separator(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// 커서 뒤 단어의 끝 위치
+func (e *CommandExtractor) wordEnd() int {
+	i := e.cursor
+	for i < len(e.line) && isNotWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && !isNotWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+func (e *CommandExtractor) moveCursor(delta int) {
+	e.cursor += delta
+	if e.cursor < 0 {
+		e.cursor = 0
+	}
+	if e.cursor > len(e.line) {
+		e.cursor = len(e.line)
+	}
+}
+
+func (e *CommandExtractor) deleteRange(from, to int) {
+	if from >= to {
+		return
+	}
+	e.line = append(e.line[:from], e.line[to:]...)
+	e.cursor = from
+}
+
+func (e *CommandExtractor) historyPrev() {
+	if e.histPos == len(e.history) {
+		e.draft = append(e.draft[:0], e.line...)
+	}
+	if e.histPos == 0 {
+		// 이 세션 이전의 셸 기록은 알 수 없음
+		e.approximate = true
+		return
+	}
+	e.histPos--
+	e.line = []rune(e.history[e.histPos])
+	e.cursor = len(e.line)
+}
+
+func (e *CommandExtractor) historyNext() {
+	if e.histPos >= len(e.history) {
+		return
+	}
+	e.histPos++
+	if e.histPos == len(e.history) {
+		e.line = append([]rune{}, e.draft...)
+	} else {
+		e.line = []rune(e.history[e.histPos])
+	}
+	e.cursor = len(e.line)
+}
+
+func (e *CommandExtractor) resetLine() {
+	e.line, e.cursor = e.line[:0], 0
+	e.approximate, e.pasted, e.started = false, false, false
+	e.histPos = len(e.history)
+	e.draft = e.draft[:0]
+	e.utf8 = e.utf8[:0]
+}
+
+func (e *CommandExtractor) submit() {
+	text := string(e.line)
+	command := Command{Approximate: e.approximate, Pasted: e.pasted}
+	prompt := e.prompt
+	e.resetLine()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	// 비밀번호 입력은 명령이 아니며 셸 기록에도 남지 않음
+	if secretPrompt.MatchString(prompt) {
+		return
+	}
+	// 셸이 기록하는 것은 프롬프트에서 실행한 명령뿐
+	if shellPrompt.MatchString(prompt) && (len(e.history) == 0 || e.history[len(e.history)-1] != text) {
+		e.history = append(e.history, text)
+		if len(e.history) > maxCommandHistory {
+			e.history = e.history[1:]
+		}
+		e.histPos = len(e.history)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			command.Line = line
+			e.emit(command)
+		}
+	}
+}
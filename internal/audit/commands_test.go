@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func extract(output string, inputs ...string) []Command {
+	var commands []Command
+	e := NewCommandExtractor(func(c Command) { commands = append(commands, c) })
+	e.Output([]byte(output))
+	for _, input := range inputs {
+		e.Input([]byte(input))
+	}
+	return commands
+}
+
+func lines(commands []Command) []string {
+	var result []string
+	for _, c := range commands {
+		result = append(result, c.Line)
+	}
+	return result
+}
+
+// TestCommandExtractor_LineEditing: 커서 이동과 삭제를 반영한 최종 줄을 추출
+func TestCommandExtractor_LineEditing(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		expected []string
+	}{
+		{"Enter variants", []string{"ls -al\r", "pwd\r\n", "id\n"}, []string{"ls -al", "pwd", "id"}},
+		{"Backspace", []string{"cat /etc/pasx\x7fswd\r"}, []string{"cat /etc/passwd"}},
+		{"Split across reads", []string{"kubectl ge", "t po\x1b", "[D\x1b[Dx\x7f", "\r"}, []string{"kubectl get po"}},
+		{"Insert in middle", []string{"rm file\x1b[D\x1b[D\x1b[D\x1b[D-f \r"}, []string{"rm -f file"}},
+		{"Home, end and delete", []string{"cho hi\x01e\x05!\x1b[H\x1b[3~E\r"}, []string{"Echo hi!"}},
+		{"Kill to start and end", []string{"wrong cmd\x15date\r", "uptime -p\x01\x06\x06\x06\x06\x06\x06\x0b\r"}, []string{"date", "uptime"}},
+		{"Delete word", []string{"git push --force\x17origin\r"}, []string{"git push origin"}},
+		{"Alt word motion", []string{"echo one two\x1bb\x1bb\x1bdthree\r"}, []string{"echo three two"}},
+		{"Ctrl-C discards line", []string{"shutdown now\x03", "whoami\r"}, []string{"whoami"}},
+		{"Blank lines ignored", []string{"\r", "   \r"}, nil},
+		{"Multibyte", []string{"echo 한", "\xea\xb8", "\x80\x7f글\r"}, []string{"echo 한글"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := extract("$ ", tt.inputs...)
+			assert.Equal(t, tt.expected, lines(commands))
+			for _, c := range commands {
+				assert.False(t, c.Approximate, c.Line)
+			}
+		})
+	}
+}
+
+// TestCommandExtractor_History: 방향키로 불러온 이 세션의 기록을 편집해 실행
+func TestCommandExtractor_History(t *testing.T) {
+	commands := extract("$ ",
+		"kubectl get pods\r",
+		"ls\r",
+		"\x1b[A\x1b[A\x7f\x7f\x7f\x7fsvc\r", // kubectl get svc
+		"vi\x1b[A\x1b[B\x1b[B!\r",           // 기록에서 돌아오면 작성 중이던 줄 복원
+		"\x1bOA\r",                          // SS3 (application cursor mode)
+	)
+	assert.Equal(t, []string{"kubectl get pods", "ls", "kubectl get svc", "vi!", "vi!"}, lines(commands))
+
+	// 세션 이전 기록은 알 수 없으므로 근사치로 표시
+	commands = extract("$ ", "\x1b[A\r", "ls\x1b[A\r")
+	assert.Equal(t, []string{"ls"}, lines(commands))
+	assert.True(t, commands[0].Approximate)
+
+	commands = extract("$ ", "cat /etc/pa\tss\r")
+	assert.Equal(t, "cat /etc/pass", commands[0].Line)
+	assert.True(t, commands[0].Approximate)
+}
+
+// TestCommandExtractor_BracketedPaste: 붙여넣은 여러 줄은 실행 시 줄마다 이벤트
+func TestCommandExtractor_BracketedPaste(t *testing.T) {
+	commands := extract("$ ", "\x1b[200~apt-get update\rapt-get install -y curl\x1b[A\x1b[201~", "\r", "ls\r")
+	assert.Equal(t, []string{"apt-get update", "apt-get install -y curl", "ls"}, lines(commands))
+	assert.True(t, commands[0].Pasted)
+	assert.True(t, commands[1].Pasted)
+	assert.False(t, commands[2].Pasted)
+}
+
+// TestCommandExtractor_Output: 비밀번호 프롬프트와 전체 화면 프로그램 입력은 제외
+func TestCommandExtractor_Output(t *testing.T) {
+	var commands []Command
+	e := NewCommandExtractor(func(c Command) { commands = append(commands, c) })
+
+	e.Output([]byte("\x1b[01;32mroot@web-0\x1b[00m:/# "))
+	e.Input([]byte("sudo -u app id\r"))
+	e.Output([]byte("sudo -u app id\r\n[sudo] password for root: "))
+	e.Input([]byte("s3cr3t\r"))
+	e.Output([]byte("\r\nuid=1000(app)\r\n$ "))
+
+	e.Input([]byte("vim app.conf\r"))
+	e.Output([]byte("\x1b[?1049h\x1b[22;0;0t\x1b[H\x1b[2J"))
+	e.Input([]byte("ihello\x1b:wq\r"))
+	e.Output([]byte("\x1b[?10"))
+	e.Output([]byte("49l\r\n$ "))
+	e.Input([]byte("cat app.conf\r"))
+
+	assert.Equal(t, []string{"sudo -u app id", "vim app.conf", "cat app.conf"}, lines(commands))
+}
+
+// TestCommandExtractor_PromptHistory: 셸 프롬프트가 아닌 곳의 입력은 기록에 남지 않음
+func TestCommandExtractor_PromptHistory(t *testing.T) {
+	var commands []Command
+	e := NewCommandExtractor(func(c Command) { commands = append(commands, c) })
+
+	// 비밀번호 입력 후 Up 은 비밀번호가 아닌 이전 명령을 불러옴
+	e.Output([]byte("$ "))
+	e.Input([]byte("sudo ls\r"))
+	e.Output([]byte("sudo ls\r\n[sudo] password for app: "))
+	e.Input([]byte("s3cr3t\r"))
+	e.Output([]byte("\r\nbin  etc\r\n$ "))
+	e.Input([]byte("\x1b[A\r"))
+
+	// read 나 y/n 질문의 응답도 셸 기록이 아님
+	e.Output([]byte("sudo ls\r\nbin  etc\r\n$ "))
+	e.Input([]byte("rm -i a.txt\r"))
+	e.Output([]byte("rm -i a.txt\r\nrm: remove regular file 'a.txt'? "))
+	e.Input([]byte("y\r"))
+	e.Output([]byte("\r\n$ "))
+	e.Input([]byte("\x1b[A\r"))
+
+	assert.Equal(t, []string{"sudo ls", "sudo ls", "rm -i a.txt", "y", "rm -i a.txt"}, lines(commands))
+	for _, c := range commands {
+		assert.NotContains(t, c.Line, "s3cr3t")
+		assert.False(t, c.Approximate, c.Line)
+	}
+}
@@ -0,0 +1,270 @@
+package recording
+
+import (
+	"bufio"
+	"cp-remote-access-api/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	castExtension     = ".cast"
+	metadataExtension = ".json"
+	defaultDir        = "recordings"
+	defaultWidth      = 80
+	defaultHeight     = 24
+)
+
+// 버퍼에 쌓인 이벤트를 파일에 쓰는 최대 지연. 비정상 종료 시 유실 범위와 실시간 재생 지연을 제한
+var flushInterval = time.Second
+
+type Config struct {
+	Enabled     bool
+	Dir         string
+	RecordStdin bool
+	Clusters    []string
+	UserTypes   []string
+}
+
+func ConfigFromEnv() *Config {
+	cfg := &Config{
+		Enabled:     config.Env.RecordingEnabled,
+		Dir:         config.Env.RecordingDir,
+		RecordStdin: config.Env.RecordingStdin,
+		Clusters:    config.SplitList(config.Env.RecordingClusters),
+		UserTypes:   config.SplitList(config.Env.RecordingUserTypes),
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = defaultDir
+	}
+	return cfg
+}
+
+// 전역 설정 또는 클러스터/사용자 유형별 설정 중 하나라도 해당되면 녹화
+func (c *Config) ShouldRecord(clusterID, userType string) bool {
+	if c.Enabled {
+		return true
+	}
+	return contains(c.Clusters, clusterID) || contains(c.UserTypes, userType)
+}
+
+type Metadata struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind,omitempty"`
+	Node      string     `json:"node,omitempty"`
+	User      string     `json:"user"`
+	UserType  string     `json:"userType"`
+	Cluster   string     `json:"cluster"`
+	Namespace string     `json:"namespace"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container"`
+	Shell     string     `json:"shell"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Duration  float64    `json:"duration,omitempty"`
+	Size      int64      `json:"size,omitempty"`
+}
+
+// asciicast v2 헤더. 세션 메타데이터는 확장 필드로 함께 저장
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Metadata  Metadata          `json:"metadata"`
+}
+
+// asciinema v2 (.cast) 형식 세션 녹화기
+type Recorder struct {
+	mu          sync.Mutex
+	dir         string
+	file        *os.File
+	writer      *bufio.Writer
+	meta        Metadata
+	recordStdin bool
+	pending     map[string][]byte
+	flushTimer  *time.Timer
+	closed      bool
+}
+
+func NewRecorder(cfg *Config, meta Metadata, width, height uint16) (*Recorder, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, err
+	}
+	if width == 0 || height == 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+	if meta.StartedAt.IsZero() {
+		meta.StartedAt = time.Now()
+	}
+
+	file, err := os.OpenFile(filepath.Join(cfg.Dir, meta.ID+castExtension), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		dir:         cfg.Dir,
+		file:        file,
+		writer:      bufio.NewWriter(file),
+		meta:        meta,
+		recordStdin: cfg.RecordStdin,
+		pending:     map[string][]byte{},
+	}
+
+	header, _ := json.Marshal(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: meta.StartedAt.Unix(),
+		Title:     fmt.Sprintf("%s@%s/%s/%s/%s", meta.User, meta.Cluster, meta.Namespace, meta.Pod, meta.Container),
+		Env:       map[string]string{"SHELL": meta.Shell, "TERM": "xterm-256color"},
+		Metadata:  meta,
+	})
+	if _, err := r.writer.Write(append(header, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := r.writer.Flush(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := r.writeMetadata(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) ID() string {
+	return r.meta.ID
+}
+
+func (r *Recorder) Input(p []byte) {
+	if r.recordStdin {
+		r.event("i", p)
+	}
+}
+
+func (r *Recorder) Output(p []byte) {
+	r.event("o", p)
+}
+
+func (r *Recorder) Resize(width, height uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeEvent("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *Recorder) event(code string, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 청크 경계에서 잘린 UTF-8 문자는 다음 청크와 합쳐서 기록
+	data := append(r.pending[code], p...)
+	cut := incompleteSuffix(data)
+	r.pending[code] = append([]byte(nil), data[len(data)-cut:]...)
+	if len(data) > cut {
+		r.writeEvent(code, string(data[:len(data)-cut]))
+	}
+}
+
+func (r *Recorder) writeEvent(code, data string) {
+	if r.closed {
+		return
+	}
+	elapsed := time.Since(r.meta.StartedAt).Seconds()
+	line, _ := json.Marshal([]interface{}{json.Number(fmt.Sprintf("%.6f", elapsed)), code, data})
+	r.writer.Write(append(line, '\n'))
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(flushInterval, r.flush)
+	}
+}
+
+// 마지막 flush 이후 기록된 이벤트를 파일에 씀
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushTimer = nil
+	if !r.closed {
+		r.writer.Flush()
+	}
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	for code, rest := range r.pending {
+		if len(rest) > 0 {
+			r.writeEvent(code, string(rest))
+		}
+	}
+	r.closed = true
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+		r.flushTimer = nil
+	}
+
+	flushErr := r.writer.Flush()
+	if info, err := r.file.Stat(); err == nil {
+		r.meta.Size = info.Size()
+	}
+	closeErr := r.file.Close()
+
+	endedAt := time.Now()
+	r.meta.EndedAt = &endedAt
+	r.meta.Duration = endedAt.Sub(r.meta.StartedAt).Seconds()
+	if err := r.writeMetadata(); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// 목록 조회용 메타데이터 파일 (<id>.json)
+func (r *Recorder) writeMetadata() error {
+	payload, err := json.Marshal(r.meta)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(r.dir, r.meta.ID+metadataExtension+".tmp")
+	if err := os.WriteFile(tmp, payload, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(r.dir, r.meta.ID+metadataExtension))
+}
+
+func incompleteSuffix(p []byte) int {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(p); i++ {
+		b := p[len(p)-i]
+		if !utf8.RuneStart(b) {
+			continue
+		}
+		if !utf8.FullRune(p[len(p)-i:]) {
+			return i
+		}
+		return 0
+	}
+	return 0
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCast: .cast 파일을 헤더와 이벤트 목록으로 분리
+func readCast(t *testing.T, path string) (map[string]interface{}, [][]interface{}) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	require.True(t, scanner.Scan(), "header line should exist")
+	var header map[string]interface{}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+
+	var events [][]interface{}
+	for scanner.Scan() {
+		var event []interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return header, events
+}
+
+// TestConfig_ShouldRecord: 전역/클러스터/사용자 유형별 녹화 대상 판단
+func TestConfig_ShouldRecord(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      Config
+		cluster  string
+		userType string
+		expected bool
+	}{
+		{name: "Disabled", cfg: Config{}, cluster: "c1", userType: "USER", expected: false},
+		{name: "Enabled globally", cfg: Config{Enabled: true}, cluster: "c1", userType: "USER", expected: true},
+		{name: "Enabled for cluster", cfg: Config{Clusters: []string{"prod"}}, cluster: "prod", userType: "USER", expected: true},
+		{name: "Other cluster", cfg: Config{Clusters: []string{"prod"}}, cluster: "dev", userType: "USER", expected: false},
+		{name: "Enabled for user type", cfg: Config{UserTypes: []string{"USER"}}, cluster: "dev", userType: "USER", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.ShouldRecord(tc.cluster, tc.userType))
+		})
+	}
+}
+
+// TestRecorder_WritesAsciicastV2: 헤더, 출력/입력/리사이즈 이벤트 및 메타데이터 파일 검증
+func TestRecorder_WritesAsciicastV2(t *testing.T) {
+	dir := t.TempDir()
+	startedAt := time.Now()
+	meta := Metadata{
+		ID:        "session-1",
+		User:      "u1",
+		UserType:  "USER",
+		Cluster:   "c1",
+		Namespace: "ns1",
+		Pod:       "p1",
+		Container: "app",
+		Shell:     "/bin/bash",
+		StartedAt: startedAt,
+	}
+
+	recorder, err := NewRecorder(&Config{Dir: dir, RecordStdin: true}, meta, 120, 40)
+	require.NoError(t, err)
+
+	recorder.Input([]byte("ls\r"))
+	recorder.Output([]byte("hello "))
+	// "한" (ED 95 9C) 이 청크 경계에서 잘린 경우
+	recorder.Output([]byte{0xED, 0x95})
+	recorder.Output([]byte{0x9C})
+	recorder.Resize(100, 30)
+	require.NoError(t, recorder.Close())
+
+	header, events := readCast(t, filepath.Join(dir, "session-1.cast"))
+	assert.Equal(t, float64(2), header["version"])
+	assert.Equal(t, float64(120), header["width"])
+	assert.Equal(t, float64(40), header["height"])
+	assert.Equal(t, float64(startedAt.Unix()), header["timestamp"])
+	assert.Equal(t, "u1@c1/ns1/p1/app", header["title"])
+	assert.Equal(t, "/bin/bash", header["env"].(map[string]interface{})["SHELL"])
+	assert.Equal(t, "u1", header["metadata"].(map[string]interface{})["user"])
+
+	require.Len(t, events, 4)
+	assert.Equal(t, []interface{}{"i", "ls\r"}, events[0][1:])
+	assert.Equal(t, []interface{}{"o", "hello "}, events[1][1:])
+	assert.Equal(t, []interface{}{"o", "한"}, events[2][1:])
+	assert.Equal(t, []interface{}{"r", "100x30"}, events[3][1:])
+	assert.GreaterOrEqual(t, events[3][0].(float64), events[0][0].(float64))
+
+	payload, err := os.ReadFile(filepath.Join(dir, "session-1.json"))
+	require.NoError(t, err)
+	var stored Metadata
+	require.NoError(t, json.Unmarshal(payload, &stored))
+	assert.Equal(t, "session-1", stored.ID)
+	assert.NotNil(t, stored.EndedAt)
+	assert.Greater(t, stored.Size, int64(0))
+}
+
+// TestRecorder_SkipsStdinByDefault: RecordStdin 미설정 시 입력 이벤트 미기록
+func TestRecorder_SkipsStdinByDefault(t *testing.T) {
+	dir := t.TempDir()
+
+	recorder, err := NewRecorder(&Config{Dir: dir}, Metadata{ID: "session-2"}, 0, 0)
+	require.NoError(t, err)
+
+	recorder.Input([]byte("secret\r"))
+	recorder.Output([]byte("$ "))
+	require.NoError(t, recorder.Close())
+
+	header, events := readCast(t, filepath.Join(dir, "session-2.cast"))
+	assert.Equal(t, float64(80), header["width"])
+	assert.Equal(t, float64(24), header["height"])
+	require.Len(t, events, 1)
+	assert.Equal(t, "o", events[0][1])
+}
+
+// TestRecorder_FlushesWhileLive: Close 전에도 기록한 이벤트가 주기적으로 파일에 반영됨
+func TestRecorder_FlushesWhileLive(t *testing.T) {
+	dir := t.TempDir()
+	original := flushInterval
+	flushInterval = 10 * time.Millisecond
+	t.Cleanup(func() { flushInterval = original })
+
+	recorder, err := NewRecorder(&Config{Dir: dir}, Metadata{ID: "session-3"}, 0, 0)
+	require.NoError(t, err)
+	defer recorder.Close()
+
+	path := filepath.Join(dir, "session-3.cast")
+	_, events := readCast(t, path)
+	assert.Empty(t, events)
+
+	recorder.Output([]byte("$ "))
+	assert.Eventually(t, func() bool {
+		_, events := readCast(t, path)
+		return len(events) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,172 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("recording not found")
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Filter struct {
+	User      string
+	Cluster   string
+	Namespace string
+	Pod       string
+	From      time.Time
+	To        time.Time
+}
+
+func (f Filter) matches(meta Metadata) bool {
+	if f.User != "" && meta.User != f.User {
+		return false
+	}
+	if f.Cluster != "" && meta.Cluster != f.Cluster {
+		return false
+	}
+	if f.Namespace != "" && meta.Namespace != f.Namespace {
+		return false
+	}
+	if f.Pod != "" && meta.Pod != f.Pod {
+		return false
+	}
+	if !f.From.IsZero() && meta.EndedAt != nil && meta.EndedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && meta.StartedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// 녹화 디렉터리 조회
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// 시작 시각 역순으로 정렬된 녹화 목록
+func (s *Store) List(filter Filter) ([]Metadata, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+metadataExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := []Metadata{}
+	for _, path := range paths {
+		meta, err := readMetadata(path)
+		if err != nil {
+			continue
+		}
+		if filter.matches(meta) {
+			recordings = append(recordings, meta)
+		}
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+func (s *Store) Get(id string) (Metadata, error) {
+	if !idPattern.MatchString(id) {
+		return Metadata{}, ErrNotFound
+	}
+	meta, err := readMetadata(filepath.Join(s.dir, id+metadataExtension))
+	if errors.Is(err, os.ErrNotExist) {
+		return Metadata{}, ErrNotFound
+	}
+	return meta, err
+}
+
+func (s *Store) Open(id string) (*os.File, error) {
+	if !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	file, err := os.Open(filepath.Join(s.dir, id+castExtension))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func readMetadata(path string) (Metadata, error) {
+	var meta Metadata
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(payload, &meta)
+	return meta, err
+}
+
+// from~to 초 구간의 이벤트만 출력. 시간은 from 기준으로 재계산하며, 구간 이전 마지막 리사이즈를 0초 이벤트로 보존
+func WriteRange(w io.Writer, cast io.Reader, from, to float64) error {
+	reader := bufio.NewReader(cast)
+	header, err := reader.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(header) > 0) {
+		return fmt.Errorf("invalid cast header: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	var lastResize json.RawMessage
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var event []json.RawMessage
+			if err := json.Unmarshal(line, &event); err != nil || len(event) != 3 {
+				return fmt.Errorf("invalid cast event: %s", strings.TrimSpace(string(line)))
+			}
+			var at float64
+			var code string
+			json.Unmarshal(event[0], &at)
+			json.Unmarshal(event[1], &code)
+
+			switch {
+			case at < from:
+				if code == "r" {
+					lastResize = event[2]
+				}
+			case to > 0 && at > to:
+				return nil
+			default:
+				if lastResize != nil {
+					if err := writeEvent(w, 0, "r", lastResize); err != nil {
+						return err
+					}
+					lastResize = nil
+				}
+				if err := writeEvent(w, at-from, code, event[2]); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return nil
+			}
+			return readErr
+		}
+	}
+}
+
+func writeEvent(w io.Writer, at float64, code string, data json.RawMessage) error {
+	line, _ := json.Marshal([]interface{}{json.Number(fmt.Sprintf("%.6f", at)), code, data})
+	_, err := w.Write(append(line, '\n'))
+	return err
+}
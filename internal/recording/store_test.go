@@ -0,0 +1,111 @@
+package recording
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFixture: 테스트용 메타데이터/캐스트 파일 생성
+func writeFixture(t *testing.T, dir string, meta Metadata) {
+	recorder, err := NewRecorder(&Config{Dir: dir}, meta, 80, 24)
+	require.NoError(t, err)
+	recorder.Output([]byte("out"))
+	require.NoError(t, recorder.Close())
+}
+
+// TestStore_List: 필터 조건 및 정렬 검증
+func TestStore_List(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	writeFixture(t, dir, Metadata{ID: "a", User: "u1", Cluster: "c1", Namespace: "ns1", Pod: "p1", StartedAt: base})
+	writeFixture(t, dir, Metadata{ID: "b", User: "u2", Cluster: "c1", Namespace: "ns2", Pod: "p2", StartedAt: base.Add(time.Hour)})
+	writeFixture(t, dir, Metadata{ID: "c", User: "u1", Cluster: "c2", Namespace: "ns1", Pod: "p3", StartedAt: base.Add(2 * time.Hour)})
+
+	store := NewStore(dir)
+	ids := func(recordings []Metadata) []string {
+		var result []string
+		for _, r := range recordings {
+			result = append(result, r.ID)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{name: "All - newest first", filter: Filter{}, expected: []string{"c", "b", "a"}},
+		{name: "By user", filter: Filter{User: "u1"}, expected: []string{"c", "a"}},
+		{name: "By cluster and namespace", filter: Filter{Cluster: "c1", Namespace: "ns2"}, expected: []string{"b"}},
+		{name: "By pod", filter: Filter{Pod: "p3"}, expected: []string{"c"}},
+		{name: "Until time", filter: Filter{To: base.Add(30 * time.Minute)}, expected: []string{"a"}},
+		{name: "No match", filter: Filter{User: "nobody"}, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recordings, err := store.List(tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ids(recordings))
+		})
+	}
+}
+
+// TestStore_GetAndOpen: 조회 및 경로 조작 차단 검증
+func TestStore_GetAndOpen(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, Metadata{ID: "abc", User: "u1"})
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.json"), []byte(`{"id":"outside"}`), 0o600))
+
+	store := NewStore(dir)
+
+	meta, err := store.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", meta.User)
+
+	file, err := store.Open("abc")
+	require.NoError(t, err)
+	file.Close()
+
+	_, err = store.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get("../outside")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Open("../outside")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestWriteRange: 시간 구간 추출 및 리사이즈 보존 검증
+func TestWriteRange(t *testing.T) {
+	cast := strings.Join([]string{
+		`{"version":2,"width":80,"height":24}`,
+		`[0.5, "o", "a"]`,
+		`[1.0, "r", "100x30"]`,
+		`[2.0, "o", "b"]`,
+		`[3.5, "o", "c"]`,
+		`[5.0, "o", "d"]`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	require.NoError(t, WriteRange(&out, strings.NewReader(cast), 2, 4))
+
+	expected := strings.Join([]string{
+		`{"version":2,"width":80,"height":24}`,
+		`[0.000000,"r","100x30"]`,
+		`[0.000000,"o","b"]`,
+		`[1.500000,"o","c"]`,
+	}, "\n") + "\n"
+	assert.Equal(t, expected, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteRange(&out, strings.NewReader(cast), 4.5, 0))
+	assert.Equal(t, `{"version":2,"width":80,"height":24}`+"\n"+`[0.000000,"r","100x30"]`+"\n"+`[0.500000,"o","d"]`+"\n", out.String())
+}
@@ -0,0 +1,172 @@
+package session
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ReasonIdleTimeout         = "IdleTimeout"
+	ReasonMaxDurationExceeded = "MaxDurationExceeded"
+)
+
+const defaultTimeoutWarning = 60 * time.Second
+
+const maxEnforceInterval = time.Second
+
+type Limits struct {
+	IdleTimeout time.Duration
+	MaxDuration time.Duration
+	Warning     time.Duration
+}
+
+// 종료 예고
+type Warning struct {
+	Reason    string
+	Remaining time.Duration
+	Message   string
+}
+
+// 기본값에 userType/클러스터별 설정을 적용. 둘 다 지정되면 더 짧은 값 사용
+func LimitsFromEnv(cluster, userType string) Limits {
+	limits := Limits{Warning: defaultTimeoutWarning}
+	if config.Env == nil {
+		return limits
+	}
+	if config.Env.SessionTimeoutWarningSeconds > 0 {
+		limits.Warning = time.Duration(config.Env.SessionTimeoutWarningSeconds) * time.Second
+	}
+	limits.IdleTimeout = resolveLimit(
+		config.Env.SessionIdleTimeoutMinutes,
+		config.Env.SessionIdleTimeoutByUserType, userType,
+		config.Env.SessionIdleTimeoutByCluster, cluster,
+	)
+	limits.MaxDuration = resolveLimit(
+		config.Env.SessionMaxDurationMinutes,
+		config.Env.SessionMaxDurationByUserType, userType,
+		config.Env.SessionMaxDurationByCluster, cluster,
+	)
+	return limits
+}
+
+func resolveLimit(defaultMinutes int, byUserType, userType, byCluster, cluster string) time.Duration {
+	var resolved time.Duration
+	for _, override := range []time.Duration{
+		parseMinutes(byUserType)[userType],
+		parseMinutes(byCluster)[cluster],
+	} {
+		if override > 0 && (resolved == 0 || override < resolved) {
+			resolved = override
+		}
+	}
+	if resolved == 0 && defaultMinutes > 0 {
+		resolved = time.Duration(defaultMinutes) * time.Minute
+	}
+	return resolved
+}
+
+// "USER=15,CLUSTER_ADMIN=60" 형식 (분 단위)
+func parseMinutes(value string) map[string]time.Duration {
+	result := map[string]time.Duration{}
+	for _, item := range config.SplitList(value) {
+		key, minutes, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(minutes)); err == nil && n > 0 {
+			result[strings.TrimSpace(key)] = time.Duration(n) * time.Minute
+		}
+	}
+	return result
+}
+
+// 가장 짧은 제한의 1/4 주기로 확인 (최대 1초)
+func (l Limits) interval() time.Duration {
+	interval := maxEnforceInterval
+	for _, d := range []time.Duration{l.IdleTimeout, l.MaxDuration} {
+		if d > 0 && d/4 < interval {
+			interval = d / 4
+		}
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return interval
+}
+
+type activity struct {
+	last atomic.Int64
+}
+
+func (a *activity) touch() {
+	a.last.Store(time.Now().UnixNano())
+}
+
+func (a *activity) since(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, a.last.Load()))
+}
+
+// 유휴 시간 및 최대 세션 시간 감시. 종료 전 warn 으로 예고하고 시간이 지나면 Terminate 호출
+func (s *Session) Enforce(ctx context.Context, limits Limits, warn func(Warning)) {
+	if limits.IdleTimeout <= 0 && limits.MaxDuration <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(limits.interval())
+	defer ticker.Stop()
+
+	idleWarned, maxWarned := false, false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if limits.MaxDuration > 0 {
+				age := now.Sub(s.info.StartedAt)
+				if age >= limits.MaxDuration {
+					s.Terminate(Termination{
+						Reason:  ReasonMaxDurationExceeded,
+						Message: fmt.Sprintf("session exceeded maximum duration of %s", limits.MaxDuration),
+					})
+					return
+				}
+				if remaining := limits.MaxDuration - age; !maxWarned && remaining <= limits.Warning {
+					maxWarned = true
+					warn(Warning{
+						Reason:    ReasonMaxDurationExceeded,
+						Remaining: remaining,
+						Message:   fmt.Sprintf("Maximum session duration of %s reached. Disconnecting in %s.", limits.MaxDuration, remaining.Round(time.Second)),
+					})
+				}
+			}
+
+			if limits.IdleTimeout > 0 {
+				idle := s.activity.since(now)
+				if idle >= limits.IdleTimeout {
+					s.Terminate(Termination{
+						Reason:  ReasonIdleTimeout,
+						Message: fmt.Sprintf("session idle for %s", limits.IdleTimeout),
+					})
+					return
+				}
+				remaining := limits.IdleTimeout - idle
+				switch {
+				case remaining > limits.Warning:
+					idleWarned = false
+				case !idleWarned:
+					idleWarned = true
+					warn(Warning{
+						Reason:    ReasonIdleTimeout,
+						Remaining: remaining,
+						Message:   fmt.Sprintf("Session has been idle. Disconnecting in %s unless there is activity.", remaining.Round(time.Second)),
+					})
+				}
+			}
+		}
+	}
+}
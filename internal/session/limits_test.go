@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLimitsFromEnv: 기본값과 userType/클러스터별 설정 적용 검증
+func TestLimitsFromEnv(t *testing.T) {
+	original := config.Env
+	defer func() { config.Env = original }()
+
+	config.Env = &config.EnvConfigs{
+		SessionIdleTimeoutMinutes:    30,
+		SessionIdleTimeoutByUserType: "USER=15, SUPER_ADMIN=120",
+		SessionIdleTimeoutByCluster:  "prod=10,invalid",
+		SessionMaxDurationMinutes:    480,
+		SessionMaxDurationByCluster:  "dev=600",
+		SessionTimeoutWarningSeconds: 30,
+	}
+
+	tests := []struct {
+		name        string
+		cluster     string
+		userType    string
+		idle        time.Duration
+		maxDuration time.Duration
+	}{
+		{"기본값", "c1", "CLUSTER_ADMIN", 30 * time.Minute, 480 * time.Minute},
+		{"userType 설정", "c1", "USER", 15 * time.Minute, 480 * time.Minute},
+		{"기본값보다 긴 설정 허용", "c1", "SUPER_ADMIN", 120 * time.Minute, 480 * time.Minute},
+		{"둘 다 지정되면 짧은 값", "prod", "SUPER_ADMIN", 10 * time.Minute, 480 * time.Minute},
+		{"클러스터 설정", "dev", "USER", 15 * time.Minute, 600 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := LimitsFromEnv(tt.cluster, tt.userType)
+			assert.Equal(t, tt.idle, limits.IdleTimeout)
+			assert.Equal(t, tt.maxDuration, limits.MaxDuration)
+			assert.Equal(t, 30*time.Second, limits.Warning)
+		})
+	}
+
+	config.Env = &config.EnvConfigs{}
+	assert.Equal(t, Limits{Warning: defaultTimeoutWarning}, LimitsFromEnv("c1", "USER"))
+}
+
+type warningRecorder struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+func (r *warningRecorder) warn(w Warning) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warnings = append(r.warnings, w)
+}
+
+func (r *warningRecorder) list() []Warning {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Warning(nil), r.warnings...)
+}
+
+// TestSession_EnforceIdleTimeout: 유휴 시 예고 후 종료, 활동이 있으면 예고 초기화
+func TestSession_EnforceIdleTimeout(t *testing.T) {
+	terminated := make(chan Termination, 1)
+	sess := New(Info{ID: "s1"}, func(term Termination) { terminated <- term })
+	warnings := &warningRecorder{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sess.Enforce(context.Background(), Limits{IdleTimeout: 200 * time.Millisecond, Warning: 100 * time.Millisecond}, warnings.warn)
+	}()
+
+	// 활동이 이어지는 동안은 종료되지 않음
+	for i := 0; i < 6; i++ {
+		time.Sleep(50 * time.Millisecond)
+		sess.Input([]byte("a"))
+	}
+	select {
+	case <-terminated:
+		t.Fatal("활동 중인 세션이 종료됨")
+	default:
+	}
+
+	select {
+	case term := <-terminated:
+		assert.Equal(t, ReasonIdleTimeout, term.Reason)
+		assert.Contains(t, term.Message, "idle")
+	case <-time.After(2 * time.Second):
+		t.Fatal("유휴 세션이 종료되지 않음")
+	}
+	<-done
+
+	got := warnings.list()
+	require.NotEmpty(t, got)
+	assert.Equal(t, ReasonIdleTimeout, got[len(got)-1].Reason)
+	assert.LessOrEqual(t, got[len(got)-1].Remaining, 100*time.Millisecond)
+}
+
+// TestSession_EnforceMaxDuration: 활동과 무관하게 최대 시간 도달 시 종료
+func TestSession_EnforceMaxDuration(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+	warnings := &warningRecorder{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			sess.Output([]byte("tick"))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	sess.Enforce(ctx, Limits{MaxDuration: 150 * time.Millisecond, Warning: 100 * time.Millisecond}, warnings.warn)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	term, ok := sess.Termination()
+	require.True(t, ok)
+	assert.Equal(t, ReasonMaxDurationExceeded, term.Reason)
+
+	got := warnings.list()
+	require.Len(t, got, 1)
+	assert.Equal(t, ReasonMaxDurationExceeded, got[0].Reason)
+}
+
+// TestSession_EnforceDisabled: 제한이 없으면 즉시 반환, ctx 종료 시 중단
+func TestSession_EnforceDisabled(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+	sess.Enforce(context.Background(), Limits{Warning: time.Second}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sess.Enforce(ctx, Limits{IdleTimeout: time.Hour}, nil)
+	_, ok := sess.Termination()
+	assert.False(t, ok)
+}
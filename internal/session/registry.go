@@ -0,0 +1,214 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const ReasonServerShutdown = "ServerShutdown"
+
+const drainPollInterval = 50 * time.Millisecond
+
+// 관리자 조회용 세션 정보
+type Info struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind,omitempty"`
+	Node      string    `json:"node,omitempty"`
+	User      string    `json:"user"`
+	UserType  string    `json:"userType"`
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Shell     string    `json:"shell,omitempty"`
+	ClientIP  string    `json:"clientIp"`
+	StartedAt time.Time `json:"startedAt"`
+	BytesIn   int64     `json:"bytesIn"`
+	BytesOut  int64     `json:"bytesOut"`
+	Watchers  []string  `json:"watchers,omitempty"`
+}
+
+// 종료 요청 정보
+type Termination struct {
+	Reason  string
+	Message string
+}
+
+type Session struct {
+	info     Info
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+	activity activity
+
+	mu          sync.Mutex
+	terminate   func(Termination)
+	termination *Termination
+	onNotice    func(string)
+
+	watchers watchers
+}
+
+func New(info Info, terminate func(Termination)) *Session {
+	if info.StartedAt.IsZero() {
+		info.StartedAt = time.Now()
+	}
+	s := &Session{info: info, terminate: terminate}
+	s.activity.touch()
+	return s
+}
+
+func (s *Session) ID() string {
+	return s.info.ID
+}
+
+func (s *Session) Info() Info {
+	info := s.info
+	info.BytesIn = s.bytesIn.Load()
+	info.BytesOut = s.bytesOut.Load()
+	info.Watchers = s.watcherUsers()
+	return info
+}
+
+func (s *Session) Input(p []byte) {
+	s.bytesIn.Add(int64(len(p)))
+	s.activity.touch()
+}
+
+func (s *Session) Output(p []byte) {
+	s.bytesOut.Add(int64(len(p)))
+	s.activity.touch()
+	s.watchers.output(p)
+}
+
+func (s *Session) Resize(width, height uint16) {}
+
+// 세션 소유자 터미널에 안내 문구를 전달하는 함수 등록
+func (s *Session) OnNotice(notify func(string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onNotice = notify
+}
+
+func (s *Session) Notify(text string) {
+	s.mu.Lock()
+	notify := s.onNotice
+	s.mu.Unlock()
+	if notify != nil {
+		notify(text)
+	}
+}
+
+// 세션 강제 종료. 최초 요청만 반영
+func (s *Session) Terminate(t Termination) {
+	s.mu.Lock()
+	if s.termination != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.termination = &t
+	terminate := s.terminate
+	s.mu.Unlock()
+
+	if terminate != nil {
+		terminate(t)
+	}
+}
+
+// 강제 종료된 경우 종료 정보 반환
+func (s *Session) Termination() (Termination, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.termination == nil {
+		return Termination{}, false
+	}
+	return *s.termination, true
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sessions: map[string]*Session{}}
+}
+
+func (r *Registry) Add(s *Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[s.ID()] = s
+}
+
+func (r *Registry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+func (r *Registry) Get(id string) (*Session, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sessions[id]
+	return s, ok
+}
+
+func (r *Registry) Sessions() []*Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].info.StartedAt.Before(sessions[j].info.StartedAt)
+	})
+	return sessions
+}
+
+// 시작 시각 순 세션 목록
+func (r *Registry) List() []Info {
+	sessions := r.Sessions()
+	infos := make([]Info, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.Info())
+	}
+	return infos
+}
+
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sessions)
+}
+
+// 서버 종료 시 호출. 모든 세션에 notice 를 보내고 grace 동안 자연 종료를 기다린 뒤 남은 세션은 t 로 종료.
+// 세션이 모두 제거되거나 ctx 가 끝나면 반환
+func (r *Registry) Drain(ctx context.Context, grace time.Duration, notice string, t Termination) {
+	for _, s := range r.Sessions() {
+		s.Notify(notice)
+	}
+
+	graceCtx, cancel := context.WithTimeout(ctx, grace)
+	defer cancel()
+	r.waitEmpty(graceCtx)
+
+	for _, s := range r.Sessions() {
+		s.Terminate(t)
+	}
+	r.waitEmpty(ctx)
+}
+
+func (r *Registry) waitEmpty(ctx context.Context) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for r.Len() > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
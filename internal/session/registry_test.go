@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegistry: 등록/조회/삭제 및 시작 시각 정렬 검증
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	now := time.Now()
+
+	registry.Add(New(Info{ID: "b", StartedAt: now.Add(time.Second)}, nil))
+	registry.Add(New(Info{ID: "a", StartedAt: now}, nil))
+
+	infos := registry.List()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "a", infos[0].ID)
+	assert.Equal(t, "b", infos[1].ID)
+
+	_, ok := registry.Get("a")
+	assert.True(t, ok)
+
+	registry.Remove("a")
+	_, ok = registry.Get("a")
+	assert.False(t, ok)
+	assert.Len(t, registry.List(), 1)
+}
+
+// TestSession_ByteCounters: 입출력 바이트 집계 검증
+func TestSession_ByteCounters(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); sess.Input([]byte("ab")) }()
+		go func() { defer wg.Done(); sess.Output([]byte("abcd")) }()
+	}
+	wg.Wait()
+
+	info := sess.Info()
+	assert.Equal(t, int64(20), info.BytesIn)
+	assert.Equal(t, int64(40), info.BytesOut)
+	assert.False(t, info.StartedAt.IsZero())
+}
+
+// TestSession_Terminate: 종료 콜백은 최초 1회만 호출
+func TestSession_Terminate(t *testing.T) {
+	calls := 0
+	sess := New(Info{ID: "s1"}, func(Termination) { calls++ })
+
+	_, terminated := sess.Termination()
+	assert.False(t, terminated)
+
+	sess.Terminate(Termination{Reason: "Terminated", Message: "first"})
+	sess.Terminate(Termination{Reason: "Terminated", Message: "second"})
+
+	termination, terminated := sess.Termination()
+	assert.True(t, terminated)
+	assert.Equal(t, "first", termination.Message)
+	assert.Equal(t, 1, calls)
+}
+
+// TestRegistry_Drain: 안내 후 grace 동안 자연 종료를 기다리고 남은 세션은 강제 종료
+func TestRegistry_Drain(t *testing.T) {
+	registry := NewRegistry()
+
+	var mu sync.Mutex
+	notices := map[string]string{}
+	var terminations []Termination
+
+	graceful := New(Info{ID: "graceful"}, nil)
+	graceful.OnNotice(func(text string) {
+		mu.Lock()
+		notices["graceful"] = text
+		mu.Unlock()
+		// 안내를 받은 사용자가 스스로 종료
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			registry.Remove("graceful")
+		}()
+	})
+	stuck := New(Info{ID: "stuck"}, func(term Termination) {
+		mu.Lock()
+		terminations = append(terminations, term)
+		mu.Unlock()
+		registry.Remove("stuck")
+	})
+	stuck.OnNotice(func(text string) {
+		mu.Lock()
+		defer mu.Unlock()
+		notices["stuck"] = text
+	})
+	registry.Add(graceful)
+	registry.Add(stuck)
+
+	start := time.Now()
+	registry.Drain(context.Background(), 200*time.Millisecond, "restarting", Termination{Reason: ReasonServerShutdown})
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, 0, registry.Len())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]string{"graceful": "restarting", "stuck": "restarting"}, notices)
+	require.Len(t, terminations, 1)
+	assert.Equal(t, ReasonServerShutdown, terminations[0].Reason)
+	_, ok := graceful.Termination()
+	assert.False(t, ok)
+}
+
+// TestRegistry_DrainEmpty: 세션이 없으면 grace 를 기다리지 않음
+func TestRegistry_DrainEmpty(t *testing.T) {
+	start := time.Now()
+	NewRegistry().Drain(context.Background(), time.Minute, "restarting", Termination{Reason: ReasonServerShutdown})
+	assert.Less(t, time.Since(start), time.Second)
+}
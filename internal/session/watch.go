@@ -0,0 +1,141 @@
+package session
+
+import (
+	"sync"
+)
+
+const (
+	scrollbackLimit   = 64 * 1024
+	watcherBufferSize = 256
+)
+
+const (
+	WatchEventJoined = "joined"
+	WatchEventLeft   = "left"
+)
+
+// 관전자 참여/이탈 알림
+type WatchNotice struct {
+	Event    string
+	User     string
+	Watchers int
+}
+
+// 읽기 전용 관전자. Output 채널은 세션 종료 또는 지연 시 닫힘
+type Watcher struct {
+	id     int
+	User   string
+	Output <-chan []byte
+	ch     chan []byte
+	// Output 채널이 닫힌 뒤에만 읽을 것
+	Lagged bool
+}
+
+type watchers struct {
+	mu         sync.Mutex
+	nextID     int
+	scrollback []byte
+	watchers   map[int]*Watcher
+	closed     bool
+	notify     func(WatchNotice)
+}
+
+// 출력을 스크롤백에 보관하고 관전자에게 전달. 버퍼가 가득 찬 관전자는 연결 해제
+func (w *watchers) output(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+
+	w.scrollback = append(w.scrollback, p...)
+	if over := len(w.scrollback) - scrollbackLimit; over > 0 {
+		w.scrollback = append([]byte(nil), w.scrollback[over:]...)
+	}
+
+	if len(w.watchers) == 0 {
+		return
+	}
+	frame := append([]byte(nil), p...)
+	for id, watcher := range w.watchers {
+		select {
+		case watcher.ch <- frame:
+		default:
+			watcher.Lagged = true
+			close(watcher.ch)
+			delete(w.watchers, id)
+		}
+	}
+}
+
+// 관전자 등록. 현재 스크롤백 스냅샷과 함께 반환
+func (s *Session) Watch(user string) (*Watcher, []byte, bool) {
+	w := &s.watchers
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil, nil, false
+	}
+	if w.watchers == nil {
+		w.watchers = map[int]*Watcher{}
+	}
+	w.nextID++
+	ch := make(chan []byte, watcherBufferSize)
+	watcher := &Watcher{id: w.nextID, User: user, Output: ch, ch: ch}
+	w.watchers[watcher.id] = watcher
+	snapshot := append([]byte(nil), w.scrollback...)
+	count := len(w.watchers)
+	notify := w.notify
+	w.mu.Unlock()
+
+	if notify != nil {
+		notify(WatchNotice{Event: WatchEventJoined, User: user, Watchers: count})
+	}
+	return watcher, snapshot, true
+}
+
+func (s *Session) Unwatch(watcher *Watcher) {
+	w := &s.watchers
+	w.mu.Lock()
+	if _, ok := w.watchers[watcher.id]; ok {
+		close(watcher.ch)
+		delete(w.watchers, watcher.id)
+	}
+	count := len(w.watchers)
+	notify := w.notify
+	closed := w.closed
+	w.mu.Unlock()
+
+	if notify != nil && !closed {
+		notify(WatchNotice{Event: WatchEventLeft, User: watcher.User, Watchers: count})
+	}
+}
+
+// 관전자 참여/이탈 시 세션 소유자에게 알릴 함수 등록
+func (s *Session) OnWatch(notify func(WatchNotice)) {
+	s.watchers.mu.Lock()
+	defer s.watchers.mu.Unlock()
+	s.watchers.notify = notify
+}
+
+func (s *Session) watcherUsers() []string {
+	s.watchers.mu.Lock()
+	defer s.watchers.mu.Unlock()
+	var users []string
+	for _, watcher := range s.watchers.watchers {
+		users = append(users, watcher.User)
+	}
+	return users
+}
+
+// 세션 종료 시 모든 관전자 연결 해제
+func (s *Session) Close() {
+	w := &s.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for id, watcher := range w.watchers {
+		close(watcher.ch)
+		delete(w.watchers, id)
+	}
+}
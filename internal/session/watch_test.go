@@ -0,0 +1,83 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSession_WatchFanOut: 스크롤백 스냅샷, 출력 전달, 참여/이탈 알림 검증
+func TestSession_WatchFanOut(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+	var notices []WatchNotice
+	sess.OnWatch(func(n WatchNotice) { notices = append(notices, n) })
+
+	sess.Output([]byte("before "))
+
+	watcher, snapshot, ok := sess.Watch("admin")
+	require.True(t, ok)
+	assert.Equal(t, "before ", string(snapshot))
+	assert.Equal(t, []string{"admin"}, sess.Info().Watchers)
+
+	sess.Output([]byte("after"))
+	assert.Equal(t, "after", string(<-watcher.Output))
+
+	sess.Unwatch(watcher)
+	_, open := <-watcher.Output
+	assert.False(t, open)
+	assert.Empty(t, sess.Info().Watchers)
+
+	assert.Equal(t, []WatchNotice{
+		{Event: WatchEventJoined, User: "admin", Watchers: 1},
+		{Event: WatchEventLeft, User: "admin", Watchers: 0},
+	}, notices)
+}
+
+// TestSession_ScrollbackLimit: 스크롤백은 최근 출력만 유지
+func TestSession_ScrollbackLimit(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+
+	sess.Output(bytes.Repeat([]byte("a"), scrollbackLimit))
+	sess.Output([]byte("tail"))
+
+	_, snapshot, ok := sess.Watch("admin")
+	require.True(t, ok)
+	assert.Len(t, snapshot, scrollbackLimit)
+	assert.True(t, bytes.HasSuffix(snapshot, []byte("tail")))
+}
+
+// TestSession_LaggingWatcherDropped: 버퍼가 가득 찬 관전자는 연결 해제 (소유자 출력은 막히지 않음)
+func TestSession_LaggingWatcherDropped(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+	watcher, _, ok := sess.Watch("slow")
+	require.True(t, ok)
+
+	for i := 0; i <= watcherBufferSize; i++ {
+		sess.Output([]byte("x"))
+	}
+
+	count := 0
+	for range watcher.Output {
+		count++
+	}
+	assert.Equal(t, watcherBufferSize, count)
+	assert.True(t, watcher.Lagged)
+}
+
+// TestSession_CloseEndsWatchers: 세션 종료 시 관전자 채널이 닫히고 신규 관전 불가
+func TestSession_CloseEndsWatchers(t *testing.T) {
+	sess := New(Info{ID: "s1"}, nil)
+	watcher, _, ok := sess.Watch("admin")
+	require.True(t, ok)
+
+	sess.Close()
+
+	_, open := <-watcher.Output
+	assert.False(t, open)
+	assert.False(t, watcher.Lagged)
+
+	_, _, ok = sess.Watch("late")
+	assert.False(t, ok)
+}
@@ -0,0 +1,181 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending   = "Pending"
+	StatusRunning   = "Running"
+	StatusVerifying = "Verifying"
+	StatusVerified  = "Verified"
+	StatusFailed    = "Failed"
+)
+
+// 진행 상황 조회용 전송 정보
+type Info struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	UserType  string    `json:"userType"`
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mtime"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// 처음부터 끊김 없이 전송된 바이트. 재개 지점이며 이 구간만 해시에 반영
+	Transferred int64 `json:"transferred"`
+	// 재전송을 포함한 총 전송량
+	BytesSent int64  `json:"bytesSent"`
+	Status    string `json:"status"`
+	// 컨테이너에서 계산한 sha256
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Transfer struct {
+	mu   sync.Mutex
+	info Info
+	hash hash.Hash
+}
+
+func New(info Info) *Transfer {
+	now := time.Now()
+	if info.CreatedAt.IsZero() {
+		info.CreatedAt = now
+	}
+	info.UpdatedAt = info.CreatedAt
+	info.Status = StatusPending
+	return &Transfer{info: info, hash: sha256.New()}
+}
+
+func (t *Transfer) ID() string {
+	return t.info.ID
+}
+
+func (t *Transfer) Info() Info {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.info
+}
+
+// offset 부터 p 를 전송함. 재개 지점에서 이어지는 구간이면 해시에 누적.
+// 재개 지점 뒤의 구간은 반영하지 않으므로 호출자는 구간을 순서대로 전송해야 함
+func (t *Transfer) Sent(offset int64, p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info.BytesSent += int64(len(p))
+	t.info.UpdatedAt = time.Now()
+	if t.info.Status == StatusPending {
+		t.info.Status = StatusRunning
+	}
+	end := offset + int64(len(p))
+	if offset > t.info.Transferred || end <= t.info.Transferred {
+		return
+	}
+	// 이미 반영한 앞부분은 제외
+	t.hash.Write(p[t.info.Transferred-offset:])
+	t.info.Transferred = end
+}
+
+// 끝까지 전송되어 검증을 시작해야 하면 true. 최초 1회만 true
+func (t *Transfer) Complete() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.info.Transferred < t.info.Size || (t.info.Status != StatusPending && t.info.Status != StatusRunning) {
+		return false
+	}
+	t.info.Status = StatusVerifying
+	t.info.UpdatedAt = time.Now()
+	return true
+}
+
+// 컨테이너에서 계산한 sha256 과 전송한 내용의 해시 비교
+func (t *Transfer) Verify(sum string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info.SHA256 = sum
+	t.info.UpdatedAt = time.Now()
+	if hex.EncodeToString(t.hash.Sum(nil)) != sum {
+		t.info.Status = StatusFailed
+		t.info.Error = "sha256 mismatch: file changed during transfer"
+		return false
+	}
+	t.info.Status = StatusVerified
+	return true
+}
+
+func (t *Transfer) Fail(message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info.Status = StatusFailed
+	t.info.Error = message
+	t.info.UpdatedAt = time.Now()
+}
+
+type Registry struct {
+	mu        sync.RWMutex
+	transfers map[string]*Transfer
+}
+
+func NewRegistry() *Registry {
+	return &Registry{transfers: map[string]*Transfer{}}
+}
+
+func (r *Registry) Add(t *Transfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transfers[t.ID()] = t
+}
+
+func (r *Registry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.transfers, id)
+}
+
+func (r *Registry) Get(id string) (*Transfer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.transfers[id]
+	return t, ok
+}
+
+// 생성 시각 순 전송 목록. user 가 비어 있으면 전체
+func (r *Registry) List(user string) []Info {
+	r.mu.RLock()
+	infos := make([]Info, 0, len(r.transfers))
+	for _, t := range r.transfers {
+		if info := t.Info(); user == "" || info.User == user {
+			infos = append(infos, info)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos
+}
+
+// ttl 동안 갱신되지 않은 전송 제거. 제거한 수 반환
+func (r *Registry) Prune(ttl time.Duration) int {
+	cutoff := time.Now().Add(-ttl)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := 0
+	for id, t := range r.transfers {
+		if t.Info().UpdatedAt.Before(cutoff) {
+			delete(r.transfers, id)
+			removed++
+		}
+	}
+	return removed
+}
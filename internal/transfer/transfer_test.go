@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// TestTransfer_Resume: 끊긴 뒤 이어받은 구간과 중복 구간이 있어도 해시는 파일 순서대로 누적
+func TestTransfer_Resume(t *testing.T) {
+	tr := New(Info{ID: "t1", Size: 10})
+	assert.Equal(t, StatusPending, tr.Info().Status)
+
+	tr.Sent(0, []byte("0123"))
+	// 재개 지점 이후 구간 (병렬 요청 등)은 해시에 반영하지 않음
+	tr.Sent(6, []byte("67"))
+	assert.False(t, tr.Complete())
+	// 이미 받은 부분과 겹치는 재전송
+	tr.Sent(2, []byte("23456789"))
+
+	info := tr.Info()
+	assert.Equal(t, StatusRunning, info.Status)
+	assert.Equal(t, int64(10), info.Transferred)
+	assert.Equal(t, int64(14), info.BytesSent)
+
+	require.True(t, tr.Complete())
+	assert.False(t, tr.Complete())
+	assert.Equal(t, StatusVerifying, tr.Info().Status)
+
+	assert.True(t, tr.Verify(checksum("0123456789")))
+	info = tr.Info()
+	assert.Equal(t, StatusVerified, info.Status)
+	assert.Equal(t, checksum("0123456789"), info.SHA256)
+}
+
+// TestTransfer_VerifyMismatch: 컨테이너 쪽 해시가 다르면 실패 처리
+func TestTransfer_VerifyMismatch(t *testing.T) {
+	tr := New(Info{ID: "t1", Size: 3})
+	tr.Sent(0, []byte("abc"))
+	require.True(t, tr.Complete())
+	assert.False(t, tr.Verify(checksum("abd")))
+	assert.Equal(t, StatusFailed, tr.Info().Status)
+	assert.NotEmpty(t, tr.Info().Error)
+
+	empty := New(Info{ID: "t2"})
+	require.True(t, empty.Complete())
+	assert.True(t, empty.Verify(checksum("")))
+}
+
+// TestRegistry: 사용자별 조회와 오래된 전송 정리
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	now := time.Now()
+	registry.Add(New(Info{ID: "b", User: "u1", CreatedAt: now.Add(time.Second)}))
+	registry.Add(New(Info{ID: "a", User: "u1", CreatedAt: now}))
+	registry.Add(New(Info{ID: "c", User: "u2", CreatedAt: now.Add(-2 * time.Hour)}))
+
+	infos := registry.List("u1")
+	require.Len(t, infos, 2)
+	assert.Equal(t, "a", infos[0].ID)
+	assert.Equal(t, "b", infos[1].ID)
+	assert.Len(t, registry.List(""), 3)
+
+	assert.Equal(t, 1, registry.Prune(time.Hour))
+	_, ok := registry.Get("c")
+	assert.False(t, ok)
+
+	registry.Remove("a")
+	assert.Len(t, registry.List(""), 1)
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"cp-remote-access-api/config"
+	"cp-remote-access-api/controller"
+	"cp-remote-access-api/internal/session"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultShutdownGrace = 30 * time.Second
+	// 세션 강제 종료 후 정리 및 REST 요청 마무리 대기 시간
+	shutdownTimeout = 10 * time.Second
+)
+
+// 종료 절차 시작 여부. true 이면 readiness 실패, 신규 websocket 연결 거부
+var draining atomic.Bool
+
+func readinessHandler(c *gin.Context) {
+	if draining.Load() {
+		c.String(http.StatusServiceUnavailable, "draining")
+		return
+	}
+	c.String(http.StatusOK, "readyz")
+}
+
+// 종료 중에는 websocket upgrade 요청을 503 으로 거부
+func RejectUpgradesWhileDraining() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining.Load() && websocket.IsWebSocketUpgrade(c.Request) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// SHUTDOWN_GRACE_SECONDS
+func shutdownGrace() time.Duration {
+	if config.Env != nil && config.Env.ShutdownGraceSeconds > 0 {
+		return time.Duration(config.Env.ShutdownGraceSeconds) * time.Second
+	}
+	return defaultShutdownGrace
+}
+
+// 활성 세션에 재시작을 알리고 grace 동안 기다린 뒤 남은 세션을 종료하고 서버를 내림
+func Shutdown(srv *http.Server, grace time.Duration) {
+	draining.Store(true)
+	slog.Info("shutting down", "activeSessions", controller.SessionRegistry.Len(), "grace", grace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace+shutdownTimeout)
+	defer cancel()
+
+	controller.SessionRegistry.Drain(ctx, grace,
+		"Server restarting. This session will be closed in "+grace.String()+".",
+		session.Termination{Reason: session.ReasonServerShutdown, Message: "server restarting"},
+	)
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+	}
+}
+
+func Init() {
+	srv := &http.Server{
+		Addr:    config.Env.ServerPort,
+		Handler: SetupRouter(),
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+	<-quit
+	Shutdown(srv, shutdownGrace())
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"cp-remote-access-api/controller"
+	"cp-remote-access-api/internal/session"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDraining: 종료 중에는 readiness 실패, websocket upgrade 거부, 일반 요청은 유지
+func TestDraining(t *testing.T) {
+	r := SetupRouter()
+	draining.Store(true)
+	t.Cleanup(func() { draining.Store(false) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/actuator/health/readiness", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/ws/exec", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "server is shutting down")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/actuator/health/liveness", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestShutdown: 활성 세션에 안내 후 grace 가 지나면 ServerShutdown 사유로 종료
+func TestShutdown(t *testing.T) {
+	t.Cleanup(func() { draining.Store(false) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: SetupRouter()}
+	go srv.Serve(listener)
+
+	notices := make(chan string, 1)
+	terminated := make(chan session.Termination, 1)
+	sess := session.New(session.Info{ID: "shutdown-test"}, func(term session.Termination) {
+		terminated <- term
+		controller.SessionRegistry.Remove("shutdown-test")
+	})
+	sess.OnNotice(func(text string) { notices <- text })
+	controller.SessionRegistry.Add(sess)
+
+	start := time.Now()
+	Shutdown(srv, 100*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	assert.Contains(t, <-notices, "Server restarting")
+	term := <-terminated
+	assert.Equal(t, session.ReasonServerShutdown, term.Reason)
+	assert.Equal(t, 0, controller.SessionRegistry.Len())
+
+	_, err = http.Get("http://" + listener.Addr().String() + "/actuator/health")
+	assert.Error(t, err, "종료 후 연결 거부")
+}
@@ -33,10 +33,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
 		} else {
+			// "bearer, <token>" 형식. 채널 서브프로토콜이 함께 전달될 수 있음
 			wsHeader := c.GetHeader("Sec-WebSocket-Protocol")
 			parts := strings.Split(wsHeader, ",")
-			if len(parts) == 2 && strings.TrimSpace(parts[0]) == "bearer" {
-				tokenString = strings.TrimSpace(parts[1])
+			for i := 0; i+1 < len(parts); i++ {
+				if strings.TrimSpace(parts[i]) == "bearer" {
+					tokenString = strings.TrimSpace(parts[i+1])
+					break
+				}
 			}
 		}
 
@@ -93,3 +97,26 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AuthMiddleware 이후에 사용. 허용된 userType 클레임만 통과
+func RequireUserType(userTypes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, _ := c.Get("claims")
+		claims, ok := val.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, "TOKEN_FAILED")
+			return
+		}
+
+		userType, _ := claims["userType"].(string)
+		for _, allowed := range userTypes {
+			if userType == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		slog.Warn("Access denied", "userAuthId", claims["userAuthId"], "userType", userType, "path", c.FullPath())
+		c.AbortWithStatusJSON(http.StatusForbidden, "ApiAccessDenied")
+	}
+}
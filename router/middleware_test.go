@@ -85,6 +85,15 @@ func TestAuthMiddleware(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedBody:       `{"message":"passed"}`,
 		},
+		{
+			name: "Success - Valid WebSocket Protocol Token with channel subprotocol",
+			setupRequest: func(req *http.Request) {
+				token, _ := generateTestToken(jwtSecret, validClaims)
+				req.Header.Set("Sec-WebSocket-Protocol", "v5.channel.k8s.io, bearer, "+token)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"message":"passed"}`,
+		},
 		{
 			name:               "Failure - No Token Provided",
 			setupRequest:       func(req *http.Request) {},
@@ -165,3 +174,73 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+// TestRequireUserType: userType 클레임 기반 접근 제어 검증
+func TestRequireUserType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name               string
+		claims             interface{}
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Success - SUPER_ADMIN",
+			claims:             jwt.MapClaims{"userAuthId": "admin", "userType": "SUPER_ADMIN"},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"message":"passed"}`,
+		},
+		{
+			name:               "Success - CLUSTER_ADMIN",
+			claims:             jwt.MapClaims{"userAuthId": "admin", "userType": "CLUSTER_ADMIN"},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"message":"passed"}`,
+		},
+		{
+			name:               "Failure - USER",
+			claims:             jwt.MapClaims{"userAuthId": "user", "userType": "USER"},
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       `"ApiAccessDenied"`,
+		},
+		{
+			name:               "Failure - Missing userType claim",
+			claims:             jwt.MapClaims{"userAuthId": "user"},
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       `"ApiAccessDenied"`,
+		},
+		{
+			name:               "Failure - No claims",
+			claims:             nil,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       `"TOKEN_FAILED"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange: AuthMiddleware 대신 클레임 직접 주입
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Set("claims", tc.claims)
+				}
+				c.Next()
+			})
+			r.Use(RequireUserType("SUPER_ADMIN", "CLUSTER_ADMIN"))
+			r.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "passed"})
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+
+			// Act: 요청 수행
+			r.ServeHTTP(w, req)
+
+			// Assert: 상태 코드 및 바디 검증
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package router
 
 import (
-	"cp-remote-access-api/config"
 	"cp-remote-access-api/controller"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +8,7 @@ import (
 
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
-	r.Use(CORSMiddleware())
+	r.Use(CORSMiddleware(), RejectUpgradesWhileDraining())
 
 	health := r.Group("/actuator/health")
 	{
@@ -19,22 +18,48 @@ func SetupRouter() *gin.Engine {
 		health.GET("/liveness", func(c *gin.Context) {
 			c.String(200, "livez")
 		})
-		health.GET("/readiness", func(c *gin.Context) {
-			c.String(200, "readyz")
-		})
+		health.GET("/readiness", readinessHandler)
 	}
 
 	api := r.Group("/")
 	api.Use(AuthMiddleware())
 	{
 		api.GET("/ws/exec", controller.ExecWebSocketHandler)
+		api.GET("/ws/attach", controller.AttachWebSocketHandler)
+		api.GET("/ws/debug", controller.DebugWebSocketHandler)
+		api.GET("/ws/logs", controller.LogsWebSocketHandler)
+		api.GET("/ws/portforward", controller.PortForwardWebSocketHandler)
 		api.GET("/shell/check", controller.CheckShellHandler)
+		api.POST("/exec", controller.ExecCommandHandler)
+		api.GET("/files/download", controller.DownloadFileHandler)
+		api.POST("/files/upload", controller.UploadFileHandler)
+		api.GET("/files/list", controller.ListFilesHandler)
+		api.GET("/files/stat", controller.StatFileHandler)
+		api.GET("/files/content", controller.ReadFileContentHandler)
+		api.PUT("/files/content", controller.WriteFileContentHandler)
+		api.POST("/files/transfers", controller.CreateTransferHandler)
+		api.GET("/files/transfers", controller.ListTransfersHandler)
+		api.GET("/files/transfers/:id", controller.GetTransferHandler)
+		api.GET("/files/transfers/:id/content", controller.TransferContentHandler)
+		api.HEAD("/files/transfers/:id/content", controller.TransferContentHandler)
+		api.Any("/proxy/:clusterId/:namespace/:kind/:target/*path", controller.ProxyHandler)
+		api.POST("/proxy-tokens", controller.CreateProxyTokenHandler)
 	}
 
-	return r
-}
+	// 브라우저용 프록시. 경로의 서명된 토큰으로 인증
+	r.Any("/proxy-token/:token/*path", controller.ProxyTokenHandler)
 
-func Init() {
-	r := SetupRouter()
-	r.Run(config.Env.ServerPort)
+	admin := r.Group("/")
+	admin.Use(AuthMiddleware(), RequireUserType("SUPER_ADMIN", "CLUSTER_ADMIN"))
+	{
+		admin.GET("/recordings", controller.ListRecordingsHandler)
+		admin.GET("/recordings/:id", controller.GetRecordingHandler)
+		admin.GET("/recordings/:id/cast", controller.GetRecordingCastHandler)
+		admin.GET("/sessions", controller.ListSessionsHandler)
+		admin.DELETE("/sessions/:id", controller.TerminateSessionHandler)
+		admin.GET("/ws/sessions/:id/watch", controller.WatchSessionHandler)
+		admin.GET("/ws/node-exec", controller.NodeExecWebSocketHandler)
+	}
+
+	return r
 }